@@ -0,0 +1,25 @@
+// Package broker abstrae el canal de RabbitMQ que usa el microservicio para consumir peticiones
+// RPC y publicar respuestas. *amqp.Channel implementa Channel; Fake es un broker en proceso
+// para ejecutar el handler sin un servidor AMQP.
+package broker
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Publisher publica mensajes. Es lo único que necesita el handler para responder.
+type Publisher interface {
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// Channel es la parte de *amqp.Channel que usa el microservicio.
+type Channel interface {
+	Publisher
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+}
+
+var _ Channel = (*amqp.Channel)(nil)
@@ -0,0 +1,188 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Capacidad de cada cola del Fake; publicar en una cola llena espera a que se consuma.
+const fakeQueueSize = 64
+
+// Fake es un broker AMQP en proceso. Solo soporta el exchange por defecto: la routing key es
+// el nombre de la cola, y las colas se crean al declararlas o al publicar en ellas.
+type Fake struct {
+	mu       sync.Mutex
+	queues   map[string]chan amqp.Delivery
+	closed   bool
+	lastTag  uint64
+	lastName int
+	acks     int
+	nacks    int
+}
+
+// NewFake crea un broker en proceso sin colas.
+func NewFake() *Fake {
+	return &Fake{queues: map[string]chan amqp.Delivery{}}
+}
+
+var _ Channel = (*Fake)(nil)
+var _ amqp.Acknowledger = (*Fake)(nil)
+
+func (f *Fake) queue(name string) (chan amqp.Delivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, errors.New("broker is closed")
+	}
+	q, ok := f.queues[name]
+	if !ok {
+		q = make(chan amqp.Delivery, fakeQueueSize)
+		f.queues[name] = q
+	}
+	return q, nil
+}
+
+// QueueDeclare crea la cola si no existe. Con nombre vacío genera uno, como RabbitMQ.
+func (f *Fake) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if name == "" {
+		f.mu.Lock()
+		f.lastName++
+		name = fmt.Sprintf("amq.gen-%d", f.lastName)
+		f.mu.Unlock()
+	}
+	if _, err := f.queue(name); err != nil {
+		return amqp.Queue{}, err
+	}
+	return amqp.Queue{Name: name}, nil
+}
+
+func (f *Fake) Qos(prefetchCount, prefetchSize int, global bool) error {
+	return nil
+}
+
+// Consume devuelve los mensajes de la cola. Varios consumidores de la misma cola se reparten los mensajes.
+func (f *Fake) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return f.queue(queue)
+}
+
+func (f *Fake) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if exchange != "" {
+		return fmt.Errorf("fake broker only supports the default exchange, got %q", exchange)
+	}
+	q, err := f.queue(key)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.lastTag++
+	delivery := amqp.Delivery{
+		Acknowledger:  f,
+		DeliveryTag:   f.lastTag,
+		RoutingKey:    key,
+		Headers:       msg.Headers,
+		ContentType:   msg.ContentType,
+		CorrelationId: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		MessageId:     msg.MessageId,
+		Timestamp:     msg.Timestamp,
+		Body:          msg.Body,
+	}
+	f.mu.Unlock()
+
+	select {
+	case q <- delivery:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Fake) Ack(tag uint64, multiple bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acks++
+	return nil
+}
+
+func (f *Fake) Nack(tag uint64, multiple bool, requeue bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nacks++
+	return nil
+}
+
+func (f *Fake) Reject(tag uint64, requeue bool) error {
+	return f.Nack(tag, false, requeue)
+}
+
+// Acks devuelve cuántos mensajes se confirmaron y cuántos se rechazaron.
+func (f *Fake) Acks() (acked int, nacked int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.acks, f.nacks
+}
+
+// Call hace una petición RPC como los clientes del microservicio: publica body en queue con una
+// cola de respuesta propia y espera la respuesta con el mismo CorrelationId.
+func (f *Fake) Call(ctx context.Context, queue string, body []byte) ([]byte, error) {
+	replyQueue, err := f.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	replies, err := f.queue(replyQueue.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.deleteQueue(replyQueue.Name)
+
+	correlationID := replyQueue.Name
+	err = f.PublishWithContext(ctx, "", queue, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: correlationID,
+		ReplyTo:       replyQueue.Name,
+		Body:          body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		select {
+		case reply, ok := <-replies:
+			if !ok {
+				return nil, errors.New("broker is closed")
+			}
+			if reply.CorrelationId == correlationID {
+				return reply.Body, nil
+			}
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no reply from %s: %w", queue, ctx.Err())
+		}
+	}
+}
+
+func (f *Fake) deleteQueue(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.queues, name)
+}
+
+// Close cierra todas las colas; los consumidores terminan al vaciarlas. Se debe llamar
+// cuando ya nadie publica.
+func (f *Fake) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return
+	}
+	f.closed = true
+	for name, q := range f.queues {
+		close(q)
+		delete(f.queues, name)
+	}
+}
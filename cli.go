@@ -0,0 +1,267 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/broker"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/controllers"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/e2e"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/migrations"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+const cliUsage = `Uso:
+  main                                              inicia el microservicio
+  main import-products [-format csv|json] [-dry-run] [-branch id] <archivo>
+  main export-products [-format csv|json] [-o archivo] [-branch id]
+  main migrate up [-n pasos]                        aplica las migraciones pendientes
+  main migrate down [-n pasos]                      revierte las últimas migraciones (1 por defecto)
+  main migrate status                               lista las migraciones y si están aplicadas
+  main migrate create [-dir migrations] <nombre>    crea los archivos de una nueva migración
+  main e2e [-cases archivo] [-postgres] [-workers n] [-v]
+                                                    ejecuta los casos de punta a punta sobre un broker en proceso`
+
+// runCommand ejecuta un subcomando de línea de comandos y devuelve el código de salida.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "import-products":
+		return importProductsCommand(args[1:])
+	case "export-products":
+		return exportProductsCommand(args[1:])
+	case "migrate":
+		return migrateCommand(args[1:])
+	case "e2e":
+		return e2eCommand(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+}
+
+func importProductsCommand(args []string) int {
+	fs := flag.NewFlagSet("import-products", flag.ContinueOnError)
+	format := fs.String("format", "", "formato del archivo (csv o json); por defecto según la extensión")
+	dryRun := fs.Bool("dry-run", false, "solo informar los cambios, sin guardarlos")
+	branch := fs.Uint("branch", 1, "sucursal cuyos productos se importan; 0 es el catálogo común")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+
+	path := fs.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	config.SetupDatabase()
+	config.SetupCurrency()
+	audit := models.AuditInfo{Pattern: "CLI_IMPORT_PRODUCTS", BranchID: *branch}
+	result, err := controllers.ImportProducts(audit, *format, content, *dryRun)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	out, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(out))
+	if len(result.Errors) > 0 || len(result.Conflicts) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func exportProductsCommand(args []string) int {
+	fs := flag.NewFlagSet("export-products", flag.ContinueOnError)
+	format := fs.String("format", "csv", "formato de salida (csv o json)")
+	output := fs.String("o", "", "archivo de salida; por defecto la salida estándar")
+	branch := fs.Uint("branch", 1, "sucursal cuyo menú se exporta; 0 es el catálogo común")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+
+	config.SetupDatabase()
+	config.SetupCurrency()
+	content, err := controllers.ExportCatalog(*branch, *format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *output == "" {
+		os.Stdout.Write(content)
+		return 0
+	}
+	if err := os.WriteFile(*output, content, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func migrateCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	steps := fs.Int("n", 0, "cantidad de migraciones a aplicar o revertir")
+	dir := fs.String("dir", "migrations", "directorio donde crear la migración")
+	if err := fs.Parse(args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+
+	if args[0] == "create" {
+		if fs.NArg() != 1 {
+			fmt.Fprintln(os.Stderr, cliUsage)
+			return 2
+		}
+		paths, err := migrations.Create(*dir, fs.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return 0
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+
+	config.ConnectDatabase()
+	switch args[0] {
+	case "up":
+		done, err := migrations.Up(config.DB, *steps)
+		for _, migration := range done {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("No pending migrations")
+		}
+	case "down":
+		if *steps == 0 {
+			*steps = 1
+		}
+		reverted, err := migrations.Down(config.DB, *steps)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "status":
+		statuses, err := migrations.GetStatus(config.DB)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, status := range statuses {
+			appliedAt := "pendiente"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+	return 0
+}
+
+// e2eCommand ejecuta el handler con el broker en proceso. Por defecto usa un Store en memoria, que
+// cubre los patrones de productos, comandas e items; con -postgres usa la base de DB_URL, que debe
+// tener el esquema vacío y migrado, y ejecuta los casos de los patrones que dependen de SQL
+// (reportes, búsqueda, turnos).
+func e2eCommand(args []string) int {
+	fs := flag.NewFlagSet("e2e", flag.ContinueOnError)
+	casesFile := fs.String("cases", "", "archivo JSON con los casos; por defecto los incluidos")
+	postgres := fs.Bool("postgres", false, "usar la base de datos de DB_URL en vez de un Store en memoria")
+	timeout := fs.Duration("timeout", 5*time.Second, "tiempo máximo de espera de cada respuesta")
+	verbose := fs.Bool("v", false, "mostrar la respuesta de los casos que fallan")
+	workers := fs.Int("workers", 8, "consumidores de la cola, para atender a la vez los casos con parallel")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 || *workers < 1 {
+		fmt.Fprintln(os.Stderr, cliUsage)
+		return 2
+	}
+
+	cases, err := e2e.DefaultCases()
+	if *postgres {
+		cases, err = e2e.PostgresCases()
+	}
+	if *casesFile != "" {
+		var content []byte
+		content, err = os.ReadFile(*casesFile)
+		if err == nil {
+			cases, err = e2e.LoadCases(content)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	spool, err := os.MkdirTemp("", "comandas-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(spool)
+	if err := e2e.Configure(spool); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if *postgres {
+		config.SetupDatabase()
+	} else {
+		controllers.UseStore(repository.NewMemoryStore())
+	}
+
+	fake := broker.NewFake()
+	if err := e2e.Serve(fake, e2e.Queue, *workers); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	failed := 0
+	for _, result := range e2e.Run(fake, e2e.Queue, cases, *timeout) {
+		if result.Passed {
+			fmt.Printf("PASS  %-30s %s\n", result.Pattern, result.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL  %-30s %s: %s\n", result.Pattern, result.Name, result.Failure)
+		if *verbose && result.Reply != nil {
+			fmt.Printf("      %s: %s\n", result.Reply.Message, result.Reply.Data)
+		}
+	}
+	fmt.Printf("%d cases, %d failed\n", len(cases), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
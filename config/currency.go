@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+)
+
+// Currency es la moneda en que cobra el restaurante. Todos los montos (precios, totales, pagos)
+// se guardan en unidades menores de esta moneda.
+var Currency, _ = money.Lookup("CLP")
+
+// Rounding es la regla para redondear los cálculos que no dan un monto exacto, como promedios.
+var Rounding = money.HalfUp
+
+// SetupCurrency configura la moneda con CURRENCY (CLP por defecto), el redondeo de los pagos en
+// efectivo con CASH_INCREMENT y la regla de redondeo con ROUNDING_MODE.
+func SetupCurrency() {
+	if code := os.Getenv("CURRENCY"); code != "" {
+		currency, err := money.Lookup(code)
+		if err != nil {
+			panic(err)
+		}
+		Currency = currency
+	}
+
+	if increment := os.Getenv("CASH_INCREMENT"); increment != "" {
+		value, err := strconv.ParseInt(increment, 10, 64)
+		if err != nil || value < 0 {
+			panic("invalid CASH_INCREMENT: " + increment)
+		}
+		Currency.CashIncrement = money.Amount(value)
+	}
+
+	if mode := os.Getenv("ROUNDING_MODE"); mode != "" {
+		rounding, err := money.ParseRoundingMode(mode)
+		if err != nil {
+			panic(err)
+		}
+		Rounding = rounding
+	}
+}
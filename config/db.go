@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"os"
 
-	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/migrations"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
-func SetupDatabase() {
+// ConnectDatabase abre la conexión a la base de datos sin revisar el esquema.
+// La usa el subcomando migrate, que es el que deja el esquema al día.
+func ConnectDatabase() {
 	var dbURL = os.Getenv("DB_URL")
 	if dbURL == "" {
 		panic("DB_URL environment variable missing")
@@ -26,12 +29,24 @@ func SetupDatabase() {
 		fmt.Println("Connected to database")
 	}
 
-	autoMigrate(DB)
-
+	// Cada consulta queda limitada a la sucursal de la petición; el menú del catálogo común
+	// (sucursal 0) lo ven todas
+	if err := tenant.Register(DB, tenant.CatalogTables...); err != nil {
+		panic(err)
+	}
 }
 
-func autoMigrate(connection *gorm.DB) {
-	connection.Debug().AutoMigrate(&models.Product{})
-	connection.Debug().AutoMigrate(&models.OrderItem{})
-	connection.Debug().AutoMigrate(&models.Order{})
+// SetupDatabase se conecta a la base de datos y se niega a continuar si quedan
+// migraciones sin aplicar.
+func SetupDatabase() {
+	ConnectDatabase()
+
+	pending, err := migrations.Pending(DB)
+	if err != nil {
+		panic(fmt.Errorf("failed to check schema migrations: %w", err))
+	}
+	if len(pending) > 0 {
+		panic(fmt.Errorf("database schema is not up to date: %d pending migrations starting at %04d_%s; run `migrate up`",
+			len(pending), pending[0].Version, pending[0].Name))
+	}
 }
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/printing"
+)
+
+// Printing son las impresoras de las estaciones de cocina y los reintentos de la cola de impresión.
+var Printing = printing.Settings{
+	Printers:       map[uint]map[string]string{},
+	Categories:     map[string]string{},
+	DefaultStation: "cocina",
+	Width:          "80mm",
+	MaxAttempts:    5,
+	RetryDelay:     10 * time.Second,
+}
+
+// SetupPrinting configura la impresión de tickets de cocina. Las impresoras de cada sucursal van
+// en PRINT_STATIONS_<id de la sucursal> como pares estación=impresora separados por comas (por
+// ejemplo PRINT_STATIONS_2="cocina=tcp://192.168.1.50:9100,barra=file:///tmp/barra.bin");
+// PRINT_STATIONS sin sufijo son las de la sucursal de DEFAULT_BRANCH_ID. Las estaciones se
+// asignan con STATION_CATEGORIES (categoría=estación, como "bebidas=barra") y DEFAULT_STATION,
+// comunes a todas las sucursales. PRINT_WIDTH, PRINT_MAX_ATTEMPTS y PRINT_RETRY_DELAY (como
+// "10s") ajustan el papel y los reintentos. Una sucursal sin impresoras no imprime nada.
+func SetupPrinting() {
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, "PRINT_STATIONS") {
+			continue
+		}
+		branchID, err := printingBranch(strings.TrimPrefix(name, "PRINT_STATIONS"))
+		if err != nil {
+			panic("invalid " + name + ": " + err.Error())
+		}
+		for station, target := range printingPairs(name) {
+			if _, err := printing.ParseTarget(target); err != nil {
+				panic(err)
+			}
+			if Printing.Printers[branchID] == nil {
+				Printing.Printers[branchID] = map[string]string{}
+			}
+			Printing.Printers[branchID][station] = target
+		}
+	}
+	for category, station := range printingPairs("STATION_CATEGORIES") {
+		Printing.Categories[strings.ToLower(category)] = station
+	}
+	if station := os.Getenv("DEFAULT_STATION"); station != "" {
+		Printing.DefaultStation = station
+	}
+	if width := os.Getenv("PRINT_WIDTH"); width != "" {
+		if width != "58mm" && width != "80mm" {
+			panic("invalid PRINT_WIDTH: " + width)
+		}
+		Printing.Width = width
+	}
+
+	if attempts := os.Getenv("PRINT_MAX_ATTEMPTS"); attempts != "" {
+		value, err := strconv.Atoi(attempts)
+		if err != nil || value < 1 {
+			panic("invalid PRINT_MAX_ATTEMPTS: " + attempts)
+		}
+		Printing.MaxAttempts = value
+	}
+	if delay := os.Getenv("PRINT_RETRY_DELAY"); delay != "" {
+		value, err := time.ParseDuration(delay)
+		if err != nil || value <= 0 {
+			panic("invalid PRINT_RETRY_DELAY: " + delay)
+		}
+		Printing.RetryDelay = value
+	}
+}
+
+// printingBranch devuelve la sucursal del sufijo de PRINT_STATIONS: "_2" es la sucursal 2 y sin
+// sufijo es la de DEFAULT_BRANCH_ID.
+func printingBranch(suffix string) (uint, error) {
+	if suffix == "" {
+		suffix = os.Getenv("DEFAULT_BRANCH_ID")
+		if suffix == "" {
+			return 1, nil
+		}
+	} else if !strings.HasPrefix(suffix, "_") {
+		return 0, errors.New("use PRINT_STATIONS_<branch id>")
+	} else {
+		suffix = suffix[1:]
+	}
+	id, err := strconv.ParseUint(suffix, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid branch id " + suffix)
+	}
+	return uint(id), nil
+}
+
+// printingPairs lee una variable con pares clave=valor separados por comas.
+func printingPairs(name string) map[string]string {
+	pairs := map[string]string{}
+	value := os.Getenv(name)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		key, val, ok := strings.Cut(pair, "=")
+		key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+		if !ok || key == "" || val == "" {
+			panic("invalid " + name + ": " + value)
+		}
+		pairs[key] = val
+	}
+	return pairs
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/receipt"
+)
+
+// Receipt son el encabezado, el pie, el impuesto y las propinas sugeridas de los recibos.
+var Receipt = receipt.Settings{
+	Footer:      []string{"Gracias por su visita"},
+	TaxName:     "IVA",
+	TaxRate:     1900,
+	TipPercents: []int{10},
+}
+
+// SetupReceipt configura los recibos con RECEIPT_HEADER y RECEIPT_FOOTER (líneas separadas por
+// "|"), TAX_NAME, TAX_RATE (porcentaje incluido en los precios, 0 para no imprimirlo) y
+// TIP_SUGGESTIONS (porcentajes separados por comas, vacío para no sugerir propina).
+func SetupReceipt() {
+	if header, ok := os.LookupEnv("RECEIPT_HEADER"); ok {
+		Receipt.Header = receiptLines(header)
+	}
+	if footer, ok := os.LookupEnv("RECEIPT_FOOTER"); ok {
+		Receipt.Footer = receiptLines(footer)
+	}
+	if name := os.Getenv("TAX_NAME"); name != "" {
+		Receipt.TaxName = name
+	}
+
+	if rate := os.Getenv("TAX_RATE"); rate != "" {
+		value, err := strconv.ParseFloat(strings.Replace(rate, ",", ".", 1), 64)
+		if err != nil || value < 0 || value >= 100 {
+			panic("invalid TAX_RATE: " + rate)
+		}
+		Receipt.TaxRate = int(math.Round(value * 100))
+	}
+
+	if tips, ok := os.LookupEnv("TIP_SUGGESTIONS"); ok {
+		Receipt.TipPercents = nil
+		for _, tip := range strings.Split(tips, ",") {
+			if tip = strings.TrimSpace(tip); tip == "" {
+				continue
+			}
+			value, err := strconv.Atoi(tip)
+			if err != nil || value <= 0 || value > 100 {
+				panic("invalid TIP_SUGGESTIONS: " + tips)
+			}
+			Receipt.TipPercents = append(Receipt.TipPercents, value)
+		}
+	}
+}
+
+func receiptLines(value string) []string {
+	var lines []string
+	for _, line := range strings.Split(value, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
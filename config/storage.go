@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/media"
+)
+
+var Storage media.Storage
+
+// SetupStorage configura dónde se guardan las imágenes de productos. Por defecto usa el
+// sistema de archivos local (MEDIA_DIR) y las publica bajo MEDIA_BASE_URL.
+func SetupStorage() {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		backend = "local"
+	}
+
+	switch backend {
+	case "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "./media_files"
+		}
+		baseURL := os.Getenv("MEDIA_BASE_URL")
+		if baseURL == "" {
+			baseURL = "/media"
+		}
+		local, err := media.NewLocalStorage(dir, baseURL)
+		if err != nil {
+			panic(err)
+		}
+		Storage = local
+		fmt.Println("Using local media storage at", dir)
+	default:
+		panic("unsupported STORAGE_BACKEND: " + backend)
+	}
+}
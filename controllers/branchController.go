@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+	"gorm.io/gorm"
+)
+
+// errSharedProduct se devuelve al intentar cambiar desde una sucursal un producto del catálogo común.
+var errSharedProduct = errors.New("the product belongs to the shared catalog; set a branch override instead")
+
+// errNoBranchPrice se devuelve al usar un producto del catálogo común en una sucursal que cobra en
+// otra moneda y no le puso precio propio: el precio del catálogo está en la moneda del catálogo.
+var errNoBranchPrice = errors.New("the shared catalog price is in another currency; set a branch override price")
+
+// ownProduct verifica que el producto sea de la sucursal que lo quiere modificar. Los productos
+// del catálogo común solo se cambian desde la sucursal 0; las demás usan SET_PRODUCT_OVERRIDE.
+func ownProduct(product *models.Product, branchID uint) error {
+	if product.BranchID != branchID {
+		return errSharedProduct
+	}
+	return nil
+}
+
+// branchCurrency devuelve la moneda en que cobra la sucursal: la propia si la tiene, si no la
+// configurada en CURRENCY.
+func branchCurrency(store repository.Store, branchID uint) money.Currency {
+	branch, err := store.Branches().FindByID(branchID)
+	if err != nil {
+		return db.Currency
+	}
+	return currencyByCode(branch.Currency)
+}
+
+// currencyByCode busca una moneda por su código. La configurada en CURRENCY conserva los ajustes
+// de CASH_INCREMENT, y un código vacío o desconocido vuelve a ella.
+func currencyByCode(code string) money.Currency {
+	if code == "" || code == db.Currency.Code {
+		return db.Currency
+	}
+	currency, err := money.Lookup(code)
+	if err != nil {
+		return db.Currency
+	}
+	return currency
+}
+
+// CreateBranch crea una sucursal. Solo se puede desde el catálogo común (sucursal 0).
+func (s *Service) CreateBranch(audit models.AuditInfo, name string, currency string) (*models.Branch, error) {
+	if audit.BranchID != tenant.Shared {
+		return nil, errors.New("branches can only be created from the shared catalog")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("branch name is required")
+	}
+	if currency != "" {
+		found, err := money.Lookup(currency)
+		if err != nil {
+			return nil, err
+		}
+		currency = found.Code
+	}
+
+	branch := models.Branch{Name: name, Currency: currency}
+	err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Branches().Create(&branch); err != nil {
+			return fmt.Errorf("failed to create branch: %w", err)
+		}
+		return storeAudit(tx, audit, "Branch", branch.ID, "create", nil, branch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &branch, nil
+}
+
+// GetBranches lista las sucursales. Desde el catálogo común se ven todas; desde una sucursal,
+// solo ella misma.
+func (s *Service) GetBranches(branchID uint) ([]models.Branch, error) {
+	if branchID == tenant.Shared {
+		return s.store.Branches().FindAll()
+	}
+	branch, err := s.store.Branches().FindByID(branchID)
+	if err != nil {
+		return nil, fmt.Errorf("branch not found: %w", err)
+	}
+	return []models.Branch{*branch}, nil
+}
+
+// SetProductOverride fija el precio o la disponibilidad de un producto del catálogo común en la
+// sucursal. price nil mantiene el precio del catálogo; hidden lo saca del menú de la sucursal.
+func (s *Service) SetProductOverride(audit models.AuditInfo, productName string, price *money.Amount, hidden bool) (*models.ProductOverride, error) {
+	if audit.BranchID == tenant.Shared {
+		return nil, errors.New("the shared catalog has no overrides; update the product instead")
+	}
+	if price != nil && *price <= 0 {
+		return nil, errors.New("price must be greater than zero")
+	}
+
+	override := models.ProductOverride{BranchID: audit.BranchID, Price: price, Hidden: hidden}
+	err := s.store.Transaction(func(tx repository.Store) error {
+		product, err := tx.Products().FindByName(productName)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
+		if product.BranchID != tenant.Shared {
+			return errors.New("only products of the shared catalog can be overridden")
+		}
+		override.ProductID = product.ID
+
+		var before json.RawMessage
+		if existing, err := tx.Products().FindOverride(product.ID); err == nil {
+			before = snapshot(existing)
+		}
+		if err := tx.Products().SaveOverride(&override); err != nil {
+			return fmt.Errorf("failed to save override: %w", err)
+		}
+		return storeAudit(tx, audit, "ProductOverride", product.ID, "update", before, override)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+// DeleteProductOverride quita el precio y la disponibilidad propios de la sucursal; el producto
+// vuelve a mostrarse con los del catálogo común.
+func (s *Service) DeleteProductOverride(audit models.AuditInfo, productName string) (*models.ProductOverride, error) {
+	var override *models.ProductOverride
+	err := s.store.Transaction(func(tx repository.Store) error {
+		product, err := tx.Products().FindByName(productName)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
+		override, err = tx.Products().FindOverride(product.ID)
+		if err != nil {
+			return fmt.Errorf("override not found: %w", err)
+		}
+		if err := tx.Products().DeleteOverride(override); err != nil {
+			return err
+		}
+		return storeAudit(tx, audit, "ProductOverride", product.ID, "delete", snapshot(override), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+// branchProduct devuelve el producto con el precio de la sucursal. Si la sucursal lo ocultó
+// responde ErrNotFound, como si no existiera. Si es del catálogo común, la sucursal cobra en otra
+// moneda que el catálogo y no le puso precio, responde errNoBranchPrice.
+func branchProduct(store repository.Store, branchID uint, product *models.Product) (*models.Product, error) {
+	override, err := store.Products().FindOverride(product.ID)
+	if errors.Is(err, repository.ErrNotFound) {
+		override, err = &models.ProductOverride{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if override.Hidden {
+		return nil, repository.ErrNotFound
+	}
+	if product.BranchID == tenant.Shared && override.Price == nil &&
+		branchCurrency(store, branchID).Code != branchCurrency(store, tenant.Shared).Code {
+		return nil, errNoBranchPrice
+	}
+	applied := override.Apply(*product)
+	return &applied, nil
+}
+
+// withoutHidden excluye de una consulta de productos los que la sucursal ocultó.
+func withoutHidden(query *gorm.DB, branchID uint) *gorm.DB {
+	return query.Where("NOT EXISTS (SELECT 1 FROM product_overrides po WHERE po.product_id = products.id AND po.branch_id = ? AND po.hidden)", branchID)
+}
+
+// branchPriceColumn es la expresión del precio de un producto en la sucursal, para ordenar por él.
+// El ID va como literal porque las columnas de orden no llevan parámetros.
+func branchPriceColumn(branchID uint) string {
+	return fmt.Sprintf("coalesce((SELECT po.override_price FROM product_overrides po WHERE po.product_id = products.id AND po.branch_id = %d), price)", branchID)
+}
+
+// applyOverrides reemplaza el precio de los productos del catálogo común por el de la sucursal.
+func applyOverrides(tx *gorm.DB, products []models.Product) error {
+	ids := make([]uint, 0, len(products))
+	for _, p := range products {
+		if p.BranchID == tenant.Shared {
+			ids = append(ids, p.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var overrides []models.ProductOverride
+	if err := tx.Where("product_id IN ?", ids).Find(&overrides).Error; err != nil {
+		return err
+	}
+	byProduct := map[uint]models.ProductOverride{}
+	for _, o := range overrides {
+		byProduct[o.ProductID] = o
+	}
+	for i := range products {
+		if o, ok := byProduct[products[i].ID]; ok && products[i].BranchID == tenant.Shared {
+			products[i] = o.Apply(products[i])
+		}
+	}
+	return nil
+}
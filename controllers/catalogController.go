@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+)
+
+// errDiscardImport deshace la transacción de una importación que no se debe aplicar.
+var errDiscardImport = errors.New("import discarded")
+
+// Columnas del formato CSV del catálogo, en orden.
+var catalogColumns = []string{"name", "description", "price", "category"}
+
+// parseCatalog lee un catálogo en formato "csv" o "json". Los precios del CSV se leen en currency.
+func parseCatalog(format string, content []byte, currency money.Currency) ([]models.CatalogRow, error) {
+	switch format {
+	case "json":
+		var rows []models.CatalogRow
+		if err := json.Unmarshal(content, &rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON catalog: %w", err)
+		}
+		return rows, nil
+	case "csv":
+		return parseCatalogCSV(content, currency)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, use csv or json", format)
+	}
+}
+
+func parseCatalogCSV(content []byte, currency money.Currency) ([]models.CatalogRow, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	index := map[string]int{}
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range []string{"name", "price"} {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("CSV is missing the %q column", column)
+		}
+	}
+
+	field := func(record []string, column string) string {
+		if i, ok := index[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []models.CatalogRow
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV at line %d: %w", line, err)
+		}
+
+		row := models.CatalogRow{
+			Name:        field(record, "name"),
+			Description: field(record, "description"),
+			Category:    field(record, "category"),
+			Price:       -1, // Se marca como inválido si no se puede leer
+		}
+		// En CSV el precio va en unidades mayores de la moneda ("12.50" para USD)
+		if price, err := currency.ParsePlain(field(record, "price")); err == nil {
+			row.Price = price
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ImportProducts lee un catálogo en formato "csv" o "json" y lo importa con ImportCatalog. Los
+// precios del CSV están en la moneda de la sucursal.
+func (s *Service) ImportProducts(audit models.AuditInfo, format string, content []byte, dryRun bool) (*models.ImportResult, error) {
+	currency := branchCurrency(s.store, audit.BranchID)
+	rows, err := parseCatalog(format, content, currency)
+	if err != nil {
+		return nil, err
+	}
+	return s.ImportCatalog(audit, rows, dryRun)
+}
+
+// ImportCatalog valida todas las filas y crea o actualiza los productos por nombre en una sola
+// transacción. Si hay errores o conflictos no se guarda nada. Con dryRun solo informa lo que haría.
+func (s *Service) ImportCatalog(audit models.AuditInfo, rows []models.CatalogRow, dryRun bool) (*models.ImportResult, error) {
+	result := &models.ImportResult{
+		DryRun:    dryRun,
+		Created:   []string{},
+		Updated:   []string{},
+		Unchanged: []string{},
+		Conflicts: []models.ImportIssue{},
+		Errors:    []models.ImportIssue{},
+	}
+
+	// Validación de cada fila
+	seen := map[string]int{}
+	for i, row := range rows {
+		issue := models.ImportIssue{Row: i + 1, Name: row.Name}
+		switch {
+		case strings.TrimSpace(row.Name) == "":
+			issue.Message = "name is required"
+		case strings.TrimSpace(row.Description) == "":
+			issue.Message = "description is required"
+		case row.Price <= 0:
+			issue.Message = "price must be greater than zero"
+		}
+		if issue.Message != "" {
+			result.Errors = append(result.Errors, issue)
+			continue
+		}
+		if first, ok := seen[row.Name]; ok {
+			issue.Message = fmt.Sprintf("duplicated name, first seen at row %d", first)
+			result.Conflicts = append(result.Conflicts, issue)
+			continue
+		}
+		seen[row.Name] = i + 1
+	}
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		for i, row := range rows {
+			if seen[row.Name] != i+1 {
+				continue // Fila inválida o repetida
+			}
+
+			existing, err := tx.Products().FindByName(row.Name)
+			if errors.Is(err, repository.ErrNotFound) {
+				result.Created = append(result.Created, row.Name)
+				product := models.Product{Name: row.Name, Description: row.Description, Price: row.Price, Category: row.Category}
+				if err := tx.Products().Create(&product); err != nil {
+					return fmt.Errorf("row %d: %w", i+1, err)
+				}
+				if err := tx.Products().RecordPrice(product.ID, product.Price, audit.UserID, time.Now()); err != nil {
+					return err
+				}
+				if err := storeAudit(tx, audit, "Product", product.ID, "create", nil, product); err != nil {
+					return err
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if existing.BranchID != audit.BranchID {
+				// Producto del catálogo común: solo se acepta sin cambios, con el precio de la sucursal
+				shared, err := branchProduct(tx, audit.BranchID, existing)
+				switch {
+				case errors.Is(err, errNoBranchPrice):
+					result.Conflicts = append(result.Conflicts, models.ImportIssue{Row: i + 1, Name: row.Name, Message: err.Error()})
+				case err != nil && !errors.Is(err, repository.ErrNotFound):
+					return err
+				case shared != nil && shared.Description == row.Description && shared.Price == row.Price && shared.Category == row.Category:
+					result.Unchanged = append(result.Unchanged, row.Name)
+				default:
+					result.Conflicts = append(result.Conflicts, models.ImportIssue{Row: i + 1, Name: row.Name, Message: errSharedProduct.Error()})
+				}
+				continue
+			}
+
+			if existing.ArchivedAt != nil {
+				result.Conflicts = append(result.Conflicts, models.ImportIssue{Row: i + 1, Name: row.Name, Message: "product is archived; restore it first"})
+				continue
+			}
+
+			if existing.Description == row.Description && existing.Price == row.Price && existing.Category == row.Category {
+				result.Unchanged = append(result.Unchanged, row.Name)
+				continue
+			}
+
+			result.Updated = append(result.Updated, row.Name)
+			before := snapshot(existing)
+			if existing.Price != row.Price {
+				if err := tx.Products().RecordPrice(existing.ID, row.Price, audit.UserID, time.Now()); err != nil {
+					return err
+				}
+			}
+			existing.Description = row.Description
+			existing.Price = row.Price
+			existing.Category = row.Category
+			if err := tx.Products().Save(existing); err != nil {
+				return fmt.Errorf("row %d: %w", i+1, err)
+			}
+			if err := storeAudit(tx, audit, "Product", existing.ID, "update", before, existing); err != nil {
+				return err
+			}
+		}
+
+		// Todo o nada: con errores, conflictos o en modo de prueba se descarta la transacción
+		if dryRun || len(result.Errors) > 0 || len(result.Conflicts) > 0 {
+			return errDiscardImport
+		}
+		return nil
+	})
+	if errors.Is(err, errDiscardImport) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// ExportCatalog genera el menú de la sucursal (productos no archivados, con los precios de la
+// sucursal, en CSV en su moneda) en el mismo formato que acepta la importación. Si algún producto
+// del catálogo común no tiene precio en la moneda de la sucursal no se exporta nada.
+func ExportCatalog(branchID uint, format string) ([]byte, error) {
+	products, err := GetAllProducts(branchID, false, models.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	store := defaultService(branchID).store
+	rows := make([]models.CatalogRow, 0, len(products))
+	var unpriced []string
+	for _, p := range products {
+		if p.BranchID == tenant.Shared {
+			if _, err := branchProduct(store, branchID, &p); errors.Is(err, errNoBranchPrice) {
+				unpriced = append(unpriced, p.Name)
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		rows = append(rows, models.CatalogRow{Name: p.Name, Description: p.Description, Price: p.Price, Category: p.Category})
+	}
+	if len(unpriced) > 0 {
+		return nil, fmt.Errorf("%w: %s", errNoBranchPrice, strings.Join(unpriced, ", "))
+	}
+
+	switch format {
+	case "json":
+		return json.MarshalIndent(rows, "", "  ")
+	case "csv":
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		currency := branchCurrency(store, branchID)
+		writer.Write(catalogColumns)
+		for _, row := range rows {
+			writer.Write([]string{row.Name, row.Description, currency.PlainString(row.Price), row.Category})
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, errors.New("unsupported format, use csv or json")
+	}
+}
@@ -0,0 +1,158 @@
+package controllers_test
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/controllers"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/migrations"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// postgresDB abre la base de TEST_DB_URL con el esquema al día, o salta la prueba si no está
+// definida. Es una variable aparte de DB_URL para no escribir por error en una base de verdad.
+func postgresDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set; this test needs a Postgres database")
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	if err := tenant.Register(conn, tenant.CatalogTables...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(conn, 0); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// Varios meseros agregan items a la misma mesa libre a la vez: todos deben quedar en una sola
+// comanda pendiente (índice idx_orders_open_table y FindOrCreateOpen) con el total correcto.
+func TestAddOrderItemConcurrentPostgres(t *testing.T) {
+	conn := postgresDB(t)
+	service := controllers.NewService(repository.NewGormStore(conn).Branch(1))
+
+	suffix := time.Now().UnixNano()
+	product, err := service.CreateProduct(mesero, fmt.Sprintf("Concurrencia %d", suffix), "prueba", 1000, "fondos")
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	table := 100000 + int(suffix%100000)
+
+	scoped := tenant.Scope(conn, 1)
+	t.Cleanup(func() {
+		orders := scoped.Model(&models.Order{}).Select("id").Where("table_number = ?", table)
+		scoped.Where("order_id IN (?)", orders).Delete(&models.OrderItem{})
+		scoped.Where("table_number = ?", table).Delete(&models.Order{})
+	})
+
+	const waiters = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, waiters)
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.AddOrderItem(mesero, mesero.UserID, product.ID, 1, table, 0, false); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("AddOrderItem: %v", err)
+	}
+
+	var orders []models.Order
+	if err := scoped.Where("table_number = ? AND estado = ?", table, "Pendiente").Find(&orders).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 {
+		t.Fatalf("table %d has %d pending orders, want exactly 1", table, len(orders))
+	}
+
+	var items int64
+	if err := scoped.Model(&models.OrderItem{}).Where("order_id = ?", orders[0].ID).Count(&items).Error; err != nil {
+		t.Fatal(err)
+	}
+	if items != waiters {
+		t.Errorf("order has %d items, want %d", items, waiters)
+	}
+	if want := product.Price.Mul(waiters); orders[0].TotalAmount != want {
+		t.Errorf("order total = %d, want %d", orders[0].TotalAmount, want)
+	}
+}
+
+// Varios cajeros registran pagos parciales de la misma comanda a la vez: RegisterPayment bloquea
+// la comanda, así que los pagos aceptados suman exactamente el total y el resto se rechaza.
+func TestRegisterPaymentConcurrentPostgres(t *testing.T) {
+	conn := postgresDB(t)
+	service := controllers.NewService(repository.NewGormStore(conn).Branch(1))
+	scoped := tenant.Scope(conn, 1)
+
+	suffix := time.Now().UnixNano()
+	product, err := service.CreateProduct(mesero, fmt.Sprintf("Pagos %d", suffix), "prueba", 1000, "fondos")
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+	table := 100000 + int(suffix%100000)
+	item, err := service.AddOrderItem(mesero, mesero.UserID, product.ID, 1, table, 0, false)
+	if err != nil {
+		t.Fatalf("AddOrderItem: %v", err)
+	}
+	orderID := *item.OrderID
+
+	// Se usa el turno abierto de la sucursal, o se abre uno que se borra al terminar
+	if _, err := service.GetCurrentShift(); err != nil {
+		shift, err := service.OpenShift(mesero, 0)
+		if err != nil {
+			t.Fatalf("OpenShift: %v", err)
+		}
+		t.Cleanup(func() { scoped.Delete(&models.Shift{}, shift.ID) })
+	}
+	t.Cleanup(func() {
+		scoped.Where("order_id = ?", orderID).Delete(&models.Payment{})
+		scoped.Where("order_id = ?", orderID).Delete(&models.OrderItem{})
+		scoped.Delete(&models.Order{}, orderID)
+	})
+
+	const cashiers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < cashiers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			service.RegisterPayment(mesero, orderID, "Debito", 100, 0)
+		}()
+	}
+	wg.Wait()
+
+	var paid int64
+	if err := scoped.Model(&models.Payment{}).Where("order_id = ?", orderID).Select("coalesce(sum(amount), 0)").Scan(&paid).Error; err != nil {
+		t.Fatal(err)
+	}
+	if paid != 1000 {
+		t.Errorf("payments add up to %d, want exactly the order total 1000", paid)
+	}
+	order, err := service.GetOrderByID(orderID)
+	if err != nil {
+		t.Fatalf("GetOrderByID: %v", err)
+	}
+	if order.Estado != "Pagada" {
+		t.Errorf("order estado = %s, want Pagada", order.Estado)
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/media"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// Tamaño máximo de una imagen subida.
+const MaxImageSize = 5 << 20
+
+// Tipos de imagen aceptados y la extensión con que se guardan.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// AddProductImage guarda una imagen del producto y su miniatura en el almacenamiento configurado.
+func (s *Service) AddProductImage(audit models.AuditInfo, productName string, data []byte) (*models.ProductImage, error) {
+	if db.Storage == nil {
+		return nil, errors.New("media storage is not configured")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("image is empty")
+	}
+	if len(data) > MaxImageSize {
+		return nil, fmt.Errorf("image exceeds the maximum size of %d bytes", MaxImageSize)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported image type: %s", contentType)
+	}
+
+	product, err := s.store.Products().FindByName(productName)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+	if err := ownProduct(product, audit.BranchID); err != nil {
+		return nil, err
+	}
+
+	thumbnail, _, err := media.Thumbnail(data, media.ThumbnailWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nombre único por producto: products/<id>/<fecha>-<aleatorio>
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	base := path.Join("products", fmt.Sprint(product.ID), time.Now().Format("20060102150405")+"-"+hex.EncodeToString(suffix))
+
+	image := models.ProductImage{
+		ProductID:   product.ID,
+		ContentType: contentType,
+		Path:        base + ext,
+		ThumbPath:   base + "-thumb.jpg",
+	}
+	if image.URL, err = db.Storage.Save(image.Path, data); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	if image.ThumbnailURL, err = db.Storage.Save(image.ThumbPath, thumbnail); err != nil {
+		db.Storage.Delete(image.Path)
+		return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Images().Create(&image); err != nil {
+			return fmt.Errorf("failed to save image: %w", err)
+		}
+		return storeAudit(tx, audit, "ProductImage", image.ID, "create", nil, image)
+	})
+	if err != nil {
+		removeImageFiles(image)
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+// DeleteProductImage borra la imagen de la base de datos y sus archivos del almacenamiento.
+func (s *Service) DeleteProductImage(audit models.AuditInfo, imageID uint) (*models.ProductImage, error) {
+	var image *models.ProductImage
+	err := s.store.Transaction(func(tx repository.Store) error {
+		var err error
+		if image, err = tx.Images().FindByID(imageID); err != nil {
+			return fmt.Errorf("image not found: %w", err)
+		}
+
+		// Las imágenes del catálogo común se ven pero no se borran desde una sucursal
+		if image.BranchID != audit.BranchID {
+			return errSharedProduct
+		}
+
+		if err := tx.Images().Delete(image); err != nil {
+			return err
+		}
+		return storeAudit(tx, audit, "ProductImage", image.ID, "delete", snapshot(image), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Los archivos se borran después de confirmar; si falla quedan huérfanos, pero sin romper el menú
+	removeImageFiles(*image)
+	return image, nil
+}
+
+// removeImageFiles borra del almacenamiento la imagen y su miniatura.
+func removeImageFiles(image models.ProductImage) {
+	if db.Storage == nil {
+		return
+	}
+	db.Storage.Delete(image.Path)
+	db.Storage.Delete(image.ThumbPath)
+}
+
+// GetProductImages lista las imágenes de un producto en el orden en que se subieron.
+func (s *Service) GetProductImages(productName string) ([]models.ProductImage, error) {
+	product, err := s.store.Products().FindByName(strings.TrimSpace(productName))
+	if errors.Is(err, repository.ErrNotFound) {
+		return []models.ProductImage{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Images().FindByProduct(product.ID)
+}
+
+// AddProductImageBase64 decodifica una imagen en base64 (opcionalmente como data URL,
+// "data:image/png;base64,...") y la guarda con AddProductImage.
+func (s *Service) AddProductImageBase64(audit models.AuditInfo, productName string, encoded string) (*models.ProductImage, error) {
+	if i := strings.Index(encoded, ";base64,"); strings.HasPrefix(encoded, "data:") && i >= 0 {
+		encoded = encoded[i+len(";base64,"):]
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 image: %w", err)
+	}
+	return s.AddProductImage(audit, productName, data)
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// FireCourse envía a cocina los items retenidos de un tiempo del menú en la comanda pendiente
+// de la mesa, por ejemplo los fondos cuando la mesa terminó las entradas.
+func (s *Service) FireCourse(audit models.AuditInfo, tableNumber int, course int) ([]models.OrderItem, error) {
+	if course <= 0 {
+		return nil, errors.New("course must be greater than zero")
+	}
+
+	var ids []uint
+	err := s.store.Transaction(func(tx repository.Store) error {
+		order, err := tx.Orders().FindOpenByTable(tableNumber)
+		if err != nil {
+			return fmt.Errorf("no pending order for table %d: %w", tableNumber, err)
+		}
+		items, err := tx.OrderItems().FindByOrder(order.ID)
+		if err != nil {
+			return err
+		}
+
+		var held []models.OrderItem
+		for _, item := range items {
+			if item.Held && item.Course == course && item.Estado != models.OrderItemAnulado {
+				held = append(held, item)
+				ids = append(ids, item.ID)
+			}
+		}
+		if len(held) == 0 {
+			return fmt.Errorf("table %d has no held items in course %d", tableNumber, course)
+		}
+		return fireItems(tx, audit, order, held)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.orderItemsByID(ids)
+}
+
+// FireItems envía a cocina items retenidos sueltos, sin esperar al resto de su tiempo.
+func (s *Service) FireItems(audit models.AuditInfo, orderItemIDs []uint) ([]models.OrderItem, error) {
+	if len(orderItemIDs) == 0 {
+		return nil, errors.New("at least one order item is required")
+	}
+
+	var ids []uint
+	err := s.store.Transaction(func(tx repository.Store) error {
+		// Los items se agrupan por comanda: cada una recibe sus propios tickets
+		var orders []*models.Order
+		byOrder := map[uint][]models.OrderItem{}
+		seen := map[uint]bool{}
+		for _, id := range orderItemIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			item, err := tx.OrderItems().FindByID(id)
+			if err != nil {
+				return fmt.Errorf("OrderItem %d not found: %v", id, err)
+			}
+			if !item.Held {
+				return fmt.Errorf("order item %d is not held", id)
+			}
+			if item.Estado == models.OrderItemAnulado {
+				return fmt.Errorf("order item %d is voided", id)
+			}
+			order, err := pendingOrderOf(tx, item)
+			if err != nil {
+				return err
+			}
+			if _, ok := byOrder[order.ID]; !ok {
+				orders = append(orders, order)
+			}
+			byOrder[order.ID] = append(byOrder[order.ID], *item)
+			ids = append(ids, id)
+		}
+
+		for _, order := range orders {
+			if err := fireItems(tx, audit, order, byOrder[order.ID]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.orderItemsByID(ids)
+}
+
+// fireItems libera los items retenidos de una comanda y encola sus tickets de cocina.
+func fireItems(tx repository.Store, audit models.AuditInfo, order *models.Order, items []models.OrderItem) error {
+	now := time.Now()
+	for i := range items {
+		before := snapshot(items[i])
+		items[i].Held = false
+		items[i].FiredAt = &now
+		if err := tx.OrderItems().Save(&items[i]); err != nil {
+			return fmt.Errorf("failed to fire order item: %w", err)
+		}
+		if err := storeAudit(tx, audit, "OrderItem", items[i].ID, "fire", before, items[i]); err != nil {
+			return err
+		}
+	}
+	if err := queueKitchenTickets(tx, order, items); err != nil {
+		return err
+	}
+
+	// Guardar la comanda aumenta su versión, así quien la tenía abierta ve que cambió
+	return recalculateOrderTotal(tx, order)
+}
+
+// orderItemsByID busca los items con su producto, en el orden dado.
+func (s *Service) orderItemsByID(ids []uint) ([]models.OrderItem, error) {
+	items := make([]models.OrderItem, 0, len(ids))
+	for _, id := range ids {
+		item, err := s.store.OrderItems().FindByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch related product details: %w", err)
+		}
+		items = append(items, *item)
+	}
+	return items, nil
+}
+
+// GetKitchenItems devuelve lo que la cocina tiene que preparar: los items enviados que no están
+// listos, en el orden en que llegaron. Los retenidos no aparecen hasta disparar su tiempo.
+// station filtra por la estación que prepara cada producto; vacío muestra todas.
+func (s *Service) GetKitchenItems(station string) ([]models.OrderItem, error) {
+	items, err := s.store.OrderItems().FindForKitchen()
+	if err != nil {
+		return nil, err
+	}
+	if station == "" {
+		return items, nil
+	}
+
+	filtered := []models.OrderItem{}
+	for _, item := range items {
+		if db.Printing.StationFor(item.Product.Category) == station {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
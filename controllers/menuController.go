@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"gorm.io/gorm"
+)
+
+// GetAllProducts lista el menú de la sucursal en el idioma pedido: sus productos y los del catálogo
+// común que no ocultó, con sus precios. Los productos archivados solo se incluyen si se piden.
+func GetAllProducts(branchID uint, includeArchived bool, locale string) ([]models.Product, error) {
+	var products []models.Product
+
+	tx := branchDB(branchID)
+	query := tx.Preload("Images", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") }).Order("name")
+	if !includeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+	if err := withoutHidden(query, branchID).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(tx, products); err != nil {
+		return nil, err
+	}
+	if err := localizeProducts(repository.NewGormStore(tx), products, locale); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// RestoreProduct vuelve a publicar en el menú un producto archivado.
+func (s *Service) RestoreProduct(audit models.AuditInfo, name string) (models.Product, error) {
+	var product models.Product
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		found, err := tx.Products().FindByName(name)
+		if err == nil && found.ArchivedAt == nil {
+			err = repository.ErrNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("archived product not found: %w", err)
+		}
+		product = *found
+		if err := ownProduct(&product, audit.BranchID); err != nil {
+			return err
+		}
+		before := snapshot(product)
+
+		product.ArchivedAt = nil
+		if err := tx.Products().Save(&product); err != nil {
+			return err
+		}
+
+		return storeAudit(tx, audit, "Product", product.ID, "restore", before, product)
+	})
+	return product, err
+}
+
+// Campos por los que se pueden ordenar los productos.
+var productSortColumns = map[string]sortColumn{
+	"id":    {"id", sortInt},
+	"name":  {"name", sortString},
+	"price": {"price", sortInt},
+}
+
+// ListProducts lista productos paginados del menú de la sucursal, aplicando los filtros de params.
+// El orden por precio usa el precio de la sucursal.
+func ListProducts(branchID uint, params models.ListParams) (*models.Page[models.Product], error) {
+	tx := branchDB(branchID)
+	query := withoutHidden(tx.Model(&models.Product{}), branchID)
+	if !params.IncludeArchived {
+		query = query.Where("archived_at IS NULL")
+	}
+	if params.Category != "" {
+		query = query.Where("category = ?", params.Category)
+	}
+	query, err := applyDietaryFilter(query, params.ExcludeAllergens, params.DietaryTags)
+	if err != nil {
+		return nil, err
+	}
+
+	sortable := map[string]sortColumn{}
+	for name, column := range productSortColumns {
+		sortable[name] = column
+	}
+	sortable["price"] = sortColumn{branchPriceColumn(branchID), sortInt}
+
+	page, err := paginate(query, params, sortable, "name",
+		func(p models.Product, sortBy string) (interface{}, uint) {
+			switch sortBy {
+			case "name":
+				return p.Name, p.ID
+			case "price":
+				return p.Price, p.ID
+			default:
+				return p.ID, p.ID
+			}
+		}, "Images")
+	if err != nil {
+		return nil, err
+	}
+	if err := applyOverrides(tx, page.Items); err != nil {
+		return nil, err
+	}
+	if err := localizeProducts(repository.NewGormStore(tx), page.Items, params.Locale); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// validateTags comprueba que todas las etiquetas pertenezcan al vocabulario dado.
+func validateTags(tags []string, vocabulary map[string]string, kind string) error {
+	for _, tag := range tags {
+		if _, ok := vocabulary[tag]; !ok {
+			return fmt.Errorf("unknown %s: %s", kind, tag)
+		}
+	}
+	return nil
+}
+
+// applyDietaryFilter excluye los productos que contienen alguno de los alérgenos indicados y
+// deja solo los que tienen todas las etiquetas de dieta pedidas.
+func applyDietaryFilter(query *gorm.DB, excludeAllergens []string, dietaryTags []string) (*gorm.DB, error) {
+	if err := validateTags(excludeAllergens, models.Allergens, "allergen"); err != nil {
+		return nil, err
+	}
+	if err := validateTags(dietaryTags, models.DietaryTags, "dietary tag"); err != nil {
+		return nil, err
+	}
+
+	if len(excludeAllergens) > 0 {
+		query = query.Where("NOT jsonb_exists_any(coalesce(allergens, '[]'::jsonb), ARRAY[?])", excludeAllergens)
+	}
+	if len(dietaryTags) > 0 {
+		tags, _ := json.Marshal(dietaryTags)
+		query = query.Where("coalesce(dietary_tags, '[]'::jsonb) @> ?::jsonb", string(tags))
+	}
+	return query, nil
+}
+
+// SetProductDietary reemplaza los alérgenos y etiquetas de dieta de un producto.
+func (s *Service) SetProductDietary(audit models.AuditInfo, name string, allergens []string, dietaryTags []string) (models.Product, error) {
+	var product models.Product
+	if err := validateTags(allergens, models.Allergens, "allergen"); err != nil {
+		return product, err
+	}
+	if err := validateTags(dietaryTags, models.DietaryTags, "dietary tag"); err != nil {
+		return product, err
+	}
+	if allergens == nil {
+		allergens = []string{}
+	}
+	if dietaryTags == nil {
+		dietaryTags = []string{}
+	}
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		found, err := tx.Products().FindByName(name)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
+		product = *found
+		if err := ownProduct(&product, audit.BranchID); err != nil {
+			return err
+		}
+		before := snapshot(product)
+
+		product.Allergens = allergens
+		product.DietaryTags = dietaryTags
+		if err := tx.Products().Save(&product); err != nil {
+			return err
+		}
+
+		return storeAudit(tx, audit, "Product", product.ID, "update", before, product)
+	})
+	return product, err
+}
@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// VoidOrderItem anula un item de una comanda sin borrarlo, dejando registro del motivo,
+// quién lo hizo (el usuario del token) y qué encargado lo aprobó. El item deja de sumar al total
+// de la comanda.
+func (s *Service) VoidOrderItem(audit models.AuditInfo, orderItemID uint, reasonCode string, approval models.Approval, wasPrepared bool) (*models.OrderItem, error) {
+	return s.changeOrderItemState(audit, orderItemID, models.OrderItemAnulado, reasonCode, approval, wasPrepared)
+}
+
+// CompOrderItem marca un item como cortesía de la casa. Se mantiene en la comanda pero no se cobra.
+func (s *Service) CompOrderItem(audit models.AuditInfo, orderItemID uint, reasonCode string, approval models.Approval, wasPrepared bool) (*models.OrderItem, error) {
+	return s.changeOrderItemState(audit, orderItemID, models.OrderItemCortesia, reasonCode, approval, wasPrepared)
+}
+
+// pendingOrderOf busca la comanda del item y verifica que siga pendiente.
+func pendingOrderOf(tx repository.Store, orderItem *models.OrderItem) (*models.Order, error) {
+	if orderItem.OrderID == nil {
+		return nil, errors.New("Order not found: order item has no order")
+	}
+	order, err := tx.Orders().FindByID(*orderItem.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("Order not found: %v", err)
+	}
+	if order.Estado != "Pendiente" {
+		return nil, errors.New("only items of pending orders can be changed")
+	}
+	return order, nil
+}
+
+func (s *Service) changeOrderItemState(audit models.AuditInfo, orderItemID uint, newState string, reasonCode string, approval models.Approval, wasPrepared bool) (*models.OrderItem, error) {
+	if _, ok := models.VoidReasonCodes[reasonCode]; !ok {
+		return nil, fmt.Errorf("invalid reason code: %s", reasonCode)
+	}
+	if audit.UserID == 0 {
+		return nil, errors.New("user is required")
+	}
+	if !approval.Valid() {
+		return nil, errors.New("manager approval is required")
+	}
+	userID, managerID := audit.UserID, approval.UserID
+
+	err := s.store.Transaction(func(tx repository.Store) error {
+		orderItem, err := tx.OrderItems().FindByID(orderItemID)
+		if err != nil {
+			return fmt.Errorf("OrderItem not found: %v", err)
+		}
+		if orderItem.Estado != models.OrderItemActivo {
+			return fmt.Errorf("order item is already %s", orderItem.Estado)
+		}
+
+		order, err := pendingOrderOf(tx, orderItem)
+		if err != nil {
+			return err
+		}
+
+		before := snapshot(orderItem)
+		now := time.Now()
+		orderItem.Estado = newState
+		orderItem.ReasonCode = reasonCode
+		orderItem.VoidedBy = &userID
+		orderItem.ApprovedBy = &managerID
+		orderItem.WasPrepared = wasPrepared
+		orderItem.VoidedAt = &now
+
+		if err := tx.OrderItems().Save(orderItem); err != nil {
+			return fmt.Errorf("failed to update order item: %w", err)
+		}
+
+		if err := recalculateOrderTotal(tx, order); err != nil {
+			return err
+		}
+
+		return storeAudit(tx, audit, "OrderItem", orderItem.ID, "update", before, orderItem)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orderItem, err := s.store.OrderItems().FindByID(orderItemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch related product details: %w", err)
+	}
+
+	return orderItem, nil
+}
+
+// UpdateOrderItem cambia la cantidad, notas y modificadores de un item y recalcula su TotalPrice
+// y el TotalAmount de la comanda en una sola transacción. Si el item ya está en preparación
+// solo se permite con force y la aprobación de un encargado.
+func (s *Service) UpdateOrderItem(audit models.AuditInfo, orderItemID uint, quantity int, notes *string, modifiers []string, force bool, approval models.Approval) (*models.OrderItem, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+
+	var orderItem *models.OrderItem
+	err := s.store.Transaction(func(tx repository.Store) error {
+		var err error
+		orderItem, err = tx.OrderItems().FindByID(orderItemID)
+		if err != nil {
+			return fmt.Errorf("OrderItem not found: %v", err)
+		}
+		if orderItem.Estado != models.OrderItemActivo {
+			return fmt.Errorf("order item is %s and cannot be changed", orderItem.Estado)
+		}
+		if orderItem.KitchenStatus != models.KitchenRecibido && !(force && approval.Valid()) {
+			return errors.New("order item is already in preparation; manager approval is required")
+		}
+
+		order, err := pendingOrderOf(tx, orderItem)
+		if err != nil {
+			return err
+		}
+
+		before := snapshot(orderItem)
+		orderItem.Quantity = quantity
+		if orderItem.ProductName == "" {
+			// Items anteriores al registro del precio: se toma el precio actual del producto
+			orderItem.ProductName = orderItem.Product.Name
+			orderItem.UnitPrice = orderItem.Product.Price
+		}
+		orderItem.TotalPrice = orderItem.UnitPrice.Mul(quantity)
+		if notes != nil {
+			orderItem.Notes = *notes
+		}
+		if modifiers != nil {
+			orderItem.Modifiers = modifiers
+		}
+
+		if err := tx.OrderItems().Save(orderItem); err != nil {
+			return fmt.Errorf("failed to update order item: %w", err)
+		}
+
+		if err := recalculateOrderTotal(tx, order); err != nil {
+			return err
+		}
+
+		return storeAudit(tx, audit, "OrderItem", orderItem.ID, "update", before, orderItem)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orderItem, nil
+}
+
+// UpdateKitchenStatus registra el avance de un item en cocina.
+func (s *Service) UpdateKitchenStatus(audit models.AuditInfo, orderItemID uint, status string) (*models.OrderItem, error) {
+	switch status {
+	case models.KitchenRecibido, models.KitchenEnPreparacion, models.KitchenListo:
+	default:
+		return nil, fmt.Errorf("invalid kitchen status: %s", status)
+	}
+
+	var orderItem *models.OrderItem
+	err := s.store.Transaction(func(tx repository.Store) error {
+		var err error
+		orderItem, err = tx.OrderItems().FindByID(orderItemID)
+		if err != nil {
+			return fmt.Errorf("OrderItem not found: %v", err)
+		}
+		if orderItem.Held {
+			return errors.New("order item is held; fire its course first")
+		}
+		before := snapshot(orderItem)
+
+		orderItem.KitchenStatus = status
+		if err := tx.OrderItems().Save(orderItem); err != nil {
+			return fmt.Errorf("failed to update kitchen status: %w", err)
+		}
+
+		return storeAudit(tx, audit, "OrderItem", orderItem.ID, "update", before, orderItem)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return orderItem, nil
+}
+
+// Campos por los que se pueden ordenar los items.
+var orderItemSortColumns = map[string]sortColumn{
+	"id":          {"id", sortInt},
+	"created_at":  {"created_at", sortTime},
+	"total_price": {"total_price", sortInt},
+	"quantity":    {"quantity", sortInt},
+}
+
+// ListOrderItems lista items paginados de la sucursal con su producto, aplicando los filtros de params.
+func ListOrderItems(branchID uint, params models.ListParams) (*models.Page[models.OrderItem], error) {
+	query := branchDB(branchID).Model(&models.OrderItem{})
+	if params.Status != "" {
+		query = query.Where("estado = ?", params.Status)
+	}
+	if params.TableNumber != nil {
+		query = query.Where("table_number = ?", *params.TableNumber)
+	}
+	if params.UserID != nil {
+		query = query.Where("user_id = ?", *params.UserID)
+	}
+	if params.ProductID != nil {
+		query = query.Where("product_id = ?", *params.ProductID)
+	}
+	if params.From != nil {
+		query = query.Where("created_at >= ?", *params.From)
+	}
+	if params.To != nil {
+		query = query.Where("created_at < ?", *params.To)
+	}
+
+	return paginate(query, params, orderItemSortColumns, "created_at",
+		func(item models.OrderItem, sortBy string) (interface{}, uint) {
+			switch sortBy {
+			case "created_at":
+				return item.CreatedAt, item.ID
+			case "total_price":
+				return item.TotalPrice, item.ID
+			case "quantity":
+				return item.Quantity, item.ID
+			default:
+				return item.ID, item.ID
+			}
+		}, "Product")
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Tipos de columna por los que se puede ordenar, para reconstruir el valor del cursor.
+const (
+	sortInt    = "int"
+	sortString = "string"
+	sortTime   = "time"
+)
+
+// sortColumn describe un campo ordenable de un listado.
+type sortColumn struct {
+	column string
+	kind   string
+}
+
+// pageCursor es el contenido del cursor: el valor del campo de orden y el ID del último resultado.
+type pageCursor struct {
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func encodeCursor(value interface{}, id uint) string {
+	var v string
+	switch x := value.(type) {
+	case time.Time:
+		v = x.Format(time.RFC3339Nano)
+	default:
+		v = fmt.Sprint(x)
+	}
+	data, _ := json.Marshal(pageCursor{Value: v, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, kind string) (interface{}, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, 0, errors.New("invalid cursor")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, 0, errors.New("invalid cursor")
+	}
+
+	switch kind {
+	case sortInt:
+		v, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, 0, errors.New("invalid cursor")
+		}
+		return v, c.ID, nil
+	case sortTime:
+		v, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, 0, errors.New("invalid cursor")
+		}
+		return v, c.ID, nil
+	default:
+		return c.Value, c.ID, nil
+	}
+}
+
+// paginate aplica orden y cursor a una consulta ya filtrada y devuelve una página de resultados.
+// El orden se desempata siempre por id, de modo que el cursor es estable. sortValue devuelve el
+// valor del campo de orden y el ID de un resultado para armar el cursor siguiente. Las relaciones
+// de preloads se cargan solo para los resultados de la página.
+func paginate[T any](query *gorm.DB, params models.ListParams, sortable map[string]sortColumn, defaultSort string, sortValue func(item T, sortBy string) (interface{}, uint), preloads ...string) (*models.Page[T], error) {
+	sortBy := params.SortBy
+	if sortBy == "" {
+		sortBy = defaultSort
+	}
+	sort, ok := sortable[sortBy]
+	if !ok {
+		return nil, fmt.Errorf("cannot sort by %q", sortBy)
+	}
+
+	dir := params.SortDir
+	if dir == "" {
+		dir = "asc"
+	}
+	if dir != "asc" && dir != "desc" {
+		return nil, fmt.Errorf("invalid sort direction %q, use asc or desc", dir)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	page := &models.Page[T]{Items: []T{}}
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	if params.Cursor != "" {
+		value, id, err := decodeCursor(params.Cursor, sort.kind)
+		if err != nil {
+			return nil, err
+		}
+		op := ">"
+		if dir == "desc" {
+			op = "<"
+		}
+		query = query.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", sort.column, op, sort.column, op), value, value, id)
+	}
+
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	// Se pide un resultado de más para saber si hay otra página
+	var items []T
+	if err := query.Order(fmt.Sprintf("%s %s, id %s", sort.column, dir, dir)).Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(items) > limit {
+		items = items[:limit]
+		value, id := sortValue(items[limit-1], sortBy)
+		page.NextCursor = encodeCursor(value, id)
+	}
+	page.Items = items
+	return page, nil
+}
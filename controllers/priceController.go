@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"errors"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// GetPriceHistory devuelve los precios que ha tenido un producto en la sucursal, del más reciente
+// al más antiguo.
+func GetPriceHistory(branchID uint, productName string) ([]models.ProductPrice, error) {
+	tx := branchDB(branchID)
+	product, err := repository.NewGormStore(tx).Products().FindByName(productName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+
+	var history []models.ProductPrice
+	if err := tx.Where("product_id = ?", product.ID).Order("effective_from DESC").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// Campos por los que se puede ordenar el historial de precios.
+var priceSortColumns = map[string]sortColumn{
+	"id":             {"id", sortInt},
+	"effective_from": {"effective_from", sortTime},
+	"price":          {"price", sortInt},
+}
+
+// ListPriceHistory lista paginados los precios que ha tenido un producto en la sucursal, del más
+// reciente al más antiguo salvo que params pida otro orden.
+func ListPriceHistory(branchID uint, productName string, params models.ListParams) (*models.Page[models.ProductPrice], error) {
+	tx := branchDB(branchID)
+	product, err := repository.NewGormStore(tx).Products().FindByName(productName)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errors.New("product not found")
+		}
+		return nil, err
+	}
+	if params.SortDir == "" {
+		params.SortDir = "desc"
+	}
+
+	query := tx.Model(&models.ProductPrice{}).Where("product_id = ?", product.ID)
+	return paginate(query, params, priceSortColumns, "effective_from",
+		func(price models.ProductPrice, sortBy string) (interface{}, uint) {
+			switch sortBy {
+			case "effective_from":
+				return price.EffectiveFrom, price.ID
+			case "price":
+				return price.Price, price.ID
+			default:
+				return price.ID, price.ID
+			}
+		})
+}
+
+// GetCurrencySettings devuelve la moneda de la sucursal y sus reglas de redondeo y formato.
+func GetCurrencySettings(branchID uint) models.CurrencySettings {
+	return models.CurrencySettings{Currency: branchCurrency(defaultService(branchID).store, branchID), Rounding: db.Rounding}
+}
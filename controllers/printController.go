@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/printing"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// queueKitchenTickets encola un ticket para cada estación de la sucursal de la comanda que tiene
+// impresora y prepara alguno de los items enviados a cocina. Se llama dentro de la transacción que guarda los items, así el
+// ticket se imprime si y solo si los items quedaron guardados.
+func queueKitchenTickets(tx repository.Store, order *models.Order, items []models.OrderItem) error {
+	settings := db.Printing
+	var stations []string
+	byStation := map[string][]models.OrderItem{}
+	for _, item := range items {
+		var category string
+		if product, err := tx.Products().FindByID(item.ProductID); err == nil {
+			category = product.Category
+		}
+		station := settings.StationFor(category)
+		if !settings.HasPrinter(order.BranchID, station) {
+			continue
+		}
+		if _, ok := byStation[station]; !ok {
+			stations = append(stations, station)
+		}
+		byStation[station] = append(byStation[station], item)
+	}
+
+	now := time.Now()
+	for _, station := range stations {
+		ticket := printing.Ticket{
+			Station: station,
+			OrderID: order.ID,
+			Table:   order.TableNumber,
+			Date:    now,
+			Items:   byStation[station],
+		}
+		job := models.PrintJob{
+			OrderID:       order.ID,
+			TableNumber:   order.TableNumber,
+			Station:       station,
+			Content:       ticket.ESCPOS(settings.Width),
+			Estado:        models.PrintJobPendiente,
+			NextAttemptAt: now,
+		}
+		for _, item := range byStation[station] {
+			job.ItemIDs = append(job.ItemIDs, item.ID)
+		}
+		if err := tx.PrintJobs().Create(&job); err != nil {
+			return fmt.Errorf("failed to queue kitchen ticket: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetPrintJobs lista los trabajos de impresión de la sucursal en un estado; por defecto los
+// fallidos, que necesitan que alguien revise la impresora.
+func (s *Service) GetPrintJobs(estado string) ([]models.PrintJob, error) {
+	if estado == "" {
+		estado = models.PrintJobFallido
+	}
+	switch estado {
+	case models.PrintJobPendiente, models.PrintJobEnviando, models.PrintJobImpreso, models.PrintJobFallido:
+	default:
+		return nil, fmt.Errorf("invalid print job status: %s", estado)
+	}
+	return s.store.PrintJobs().FindByEstado(estado)
+}
+
+// Campos por los que se pueden ordenar los trabajos de impresión.
+var printJobSortColumns = map[string]sortColumn{
+	"id":              {"id", sortInt},
+	"created_at":      {"created_at", sortTime},
+	"next_attempt_at": {"next_attempt_at", sortTime},
+}
+
+// ListPrintJobs lista paginados los trabajos de impresión de la sucursal en un estado; por
+// defecto los fallidos, como GetPrintJobs.
+func ListPrintJobs(branchID uint, estado string, params models.ListParams) (*models.Page[models.PrintJob], error) {
+	if estado == "" {
+		estado = models.PrintJobFallido
+	}
+	switch estado {
+	case models.PrintJobPendiente, models.PrintJobEnviando, models.PrintJobImpreso, models.PrintJobFallido:
+	default:
+		return nil, fmt.Errorf("invalid print job status: %s", estado)
+	}
+
+	query := branchDB(branchID).Model(&models.PrintJob{}).Where("estado = ?", estado)
+	if params.TableNumber != nil {
+		query = query.Where("table_number = ?", *params.TableNumber)
+	}
+	if params.From != nil {
+		query = query.Where("created_at >= ?", *params.From)
+	}
+	if params.To != nil {
+		query = query.Where("created_at < ?", *params.To)
+	}
+
+	return paginate(query, params, printJobSortColumns, "created_at",
+		func(job models.PrintJob, sortBy string) (interface{}, uint) {
+			switch sortBy {
+			case "created_at":
+				return job.CreatedAt, job.ID
+			case "next_attempt_at":
+				return job.NextAttemptAt, job.ID
+			default:
+				return job.ID, job.ID
+			}
+		})
+}
+
+// RetryPrintJob envía ahora un trabajo pendiente o fallido, sin esperar al próximo reintento.
+// Un trabajo fallido vuelve a tener todos sus reintentos. El trabajo se toma bloqueado en una
+// transacción y se envía después de confirmarla; si la impresora no responde el resultado del
+// intento queda guardado y se devuelve el error.
+func (s *Service) RetryPrintJob(audit models.AuditInfo, jobID uint) (*models.PrintJob, error) {
+	settings := db.Printing
+	var claimed models.PrintJob
+	err := s.store.Transaction(func(tx repository.Store) error {
+		job, err := tx.PrintJobs().FindByIDForUpdate(jobID)
+		if err != nil {
+			return fmt.Errorf("print job not found: %w", err)
+		}
+		switch job.Estado {
+		case models.PrintJobImpreso:
+			return errors.New("print job was already printed; reprint it instead")
+		case models.PrintJobEnviando:
+			return errors.New("print job is being sent right now")
+		}
+		before := snapshot(job)
+
+		if job.Estado == models.PrintJobFallido {
+			job.Attempts = 0
+		}
+		settings.Claim(job, time.Now())
+		if err := tx.PrintJobs().Save(job); err != nil {
+			return err
+		}
+		claimed = *job
+		return storeAudit(tx, audit, "PrintJob", job.ID, "retry", before, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := settings.Send(claimed)
+	job, err := settings.Finish(s.store, claimed, sendErr, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if sendErr != nil {
+		return job, fmt.Errorf("failed to print: %w", sendErr)
+	}
+	return job, nil
+}
+
+// ReprintPrintJob encola de nuevo el ticket de un trabajo ya impreso o fallido, marcado como
+// reimpresión. station lo manda a otra estación, por ejemplo si su impresora está sin papel;
+// vacío usa la del trabajo original.
+func (s *Service) ReprintPrintJob(audit models.AuditInfo, jobID uint, station string) (*models.PrintJob, error) {
+	var reprint models.PrintJob
+	err := s.store.Transaction(func(tx repository.Store) error {
+		original, err := tx.PrintJobs().FindByID(jobID)
+		if err != nil {
+			return fmt.Errorf("print job not found: %w", err)
+		}
+		if original.Estado == models.PrintJobPendiente {
+			return errors.New("print job is still pending; retry it instead")
+		}
+		if station == "" {
+			station = original.Station
+		}
+		if !db.Printing.HasPrinter(original.BranchID, station) {
+			return fmt.Errorf("station %s has no printer configured", station)
+		}
+
+		reprint = models.PrintJob{
+			OrderID:       original.OrderID,
+			TableNumber:   original.TableNumber,
+			Station:       station,
+			ItemIDs:       original.ItemIDs,
+			Content:       printing.Reprint(original.Content),
+			Estado:        models.PrintJobPendiente,
+			NextAttemptAt: time.Now(),
+			ReprintOf:     &original.ID,
+		}
+		if err := tx.PrintJobs().Create(&reprint); err != nil {
+			return fmt.Errorf("failed to queue reprint: %w", err)
+		}
+		return storeAudit(tx, audit, "PrintJob", reprint.ID, "reprint", nil, reprint)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reprint, nil
+}
@@ -2,8 +2,9 @@ package controllers
 
 import (
 	"errors"
-	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
 	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
 	"gorm.io/gorm"
 	"fmt"
 	"time" 
@@ -11,11 +12,14 @@ import (
 )
 
 
-func GetByProductID(productID string) (models.Product, error) {
+// GetByProductID devuelve un producto del menú de la sucursal con sus fotos, el precio de la
+// sucursal y los textos en locale. Los productos que la sucursal ocultó no se encuentran.
+func GetByProductID(branchID uint, productID uint, locale string) (models.Product, error) {
     var product models.Product
 
-    // Buscar el producto por su product_id en la base de datos
-    if err := db.DB.Where("product_id = ?", productID).First(&product).Error; err != nil {
+    tx := branchDB(branchID)
+    query := tx.Preload("Images", func(tx *gorm.DB) *gorm.DB { return tx.Order("id") })
+    if err := withoutHidden(query, branchID).First(&product, productID).Error; err != nil {
         if err == gorm.ErrRecordNotFound {
             // Si no se encuentra el producto
             return models.Product{}, errors.New("product not found")
@@ -23,15 +27,23 @@ func GetByProductID(productID string) (models.Product, error) {
         return models.Product{}, err
     }
 
-    // Devolver el producto encontrado
-    return product, nil
+    // Mismo precio y textos que en el listado del menú
+    products := []models.Product{product}
+    if err := applyOverrides(tx, products); err != nil {
+        return models.Product{}, err
+    }
+    if err := localizeProducts(repository.NewGormStore(tx), products, locale); err != nil {
+        return models.Product{}, err
+    }
+    return products[0], nil
 }
 
-func CreateProduct(name string, description string, price int) (models.Product, error) {
-	var existingProduct models.Product
-
+func (s *Service) CreateProduct(audit models.AuditInfo, name string, description string, price money.Amount, category string) (models.Product, error) {
 	// Verificar si ya existe un producto con el mismo nombre
-	if err := db.DB.Where("name = ?", name).First(&existingProduct).Error; err == nil {
+	if existingProduct, err := s.store.Products().FindByName(name); err == nil {
+		if existingProduct.ArchivedAt != nil {
+			return models.Product{}, errors.New("an archived product with the same name exists; restore it instead")
+		}
 		return models.Product{}, errors.New("a product with the same name already exists")
 	}
 
@@ -40,108 +52,133 @@ func CreateProduct(name string, description string, price int) (models.Product,
 		Name:        name,
 		Description: description,
 		Price:       price,
+		Category:    category,
 	}
 
-	// Guardar en la base de datos
-	if err := db.DB.Create(&newProduct).Error; err != nil {
+	// Guardar el producto junto con su precio inicial y su registro de auditoría
+	err := s.store.Transaction(func(tx repository.Store) error {
+		if err := tx.Products().Create(&newProduct); err != nil {
+			return err
+		}
+		if err := tx.Products().RecordPrice(newProduct.ID, newProduct.Price, audit.UserID, time.Now()); err != nil {
+			return err
+		}
+		return storeAudit(tx, audit, "Product", newProduct.ID, "create", nil, newProduct)
+	})
+	if err != nil {
 		return models.Product{}, err
 	}
 
 	return newProduct, nil
 }
 
-func UpdateProduct(productoIngresado string, newName string, newPrice int, newDescription string) (models.Product, error) {
-	// Inicia una transacción
-	tx := db.DB.Begin()
-	defer func() {
-		// Recupera la transacción en caso de error y finaliza la función
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Consulta la base de datos para obtener el producto existente por su nombre
+func (s *Service) UpdateProduct(audit models.AuditInfo, productoIngresado string, newName string, newPrice money.Amount, newDescription string, newCategory string) (models.Product, error) {
 	var producto models.Product
-	if err := tx.Where("name = ?", productoIngresado).First(&producto).Error; err != nil {
-		tx.Rollback()
-		return producto, err
-	}
 
-	// Verifica si el nombre está siendo cambiado y si existe otro producto con el mismo nombre
-	if producto.Name != newName {
-		var duplicateProduct models.Product
-		if err := tx.Where("name = ?", newName).First(&duplicateProduct).Error; err == nil {
-			// Ya existe un producto con el nuevo nombre
-			tx.Rollback()
-			return producto, errors.New("product with the same name already exists")
-		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-			// Otro error al buscar el producto duplicado
-			tx.Rollback()
-			return producto, err
+	err := s.store.Transaction(func(tx repository.Store) error {
+		// Obtiene el producto existente por su nombre
+		found, err := tx.Products().FindByName(productoIngresado)
+		if err != nil {
+			return err
+		}
+		producto = *found
+		if err := ownProduct(&producto, audit.BranchID); err != nil {
+			return err
+		}
+		before := snapshot(producto)
+
+		// Verifica si el nombre está siendo cambiado y si existe otro producto con el mismo nombre
+		if producto.Name != newName {
+			if _, err := tx.Products().FindByName(newName); err == nil {
+				// Ya existe un producto con el nuevo nombre
+				return errors.New("product with the same name already exists")
+			} else if !errors.Is(err, repository.ErrNotFound) {
+				// Otro error al buscar el producto duplicado
+				return err
+			}
 		}
-	}
 
-	// Actualiza los campos del producto existente con los nuevos valores
-	if newName != "" {
-		producto.Name = newName
-	}
-	if newPrice > 0 {
-		producto.Price = newPrice
-	}
-	if newDescription != "" {
-		producto.Description = newDescription
-	}
-	
+		// Actualiza los campos del producto existente con los nuevos valores
+		if newName != "" {
+			producto.Name = newName
+		}
+		if newPrice > 0 && newPrice != producto.Price {
+			producto.Price = newPrice
+			// Cierra el precio vigente y abre uno nuevo en el historial
+			if err := tx.Products().RecordPrice(producto.ID, newPrice, audit.UserID, time.Now()); err != nil {
+				return err
+			}
+		}
+		if newDescription != "" {
+			producto.Description = newDescription
+		}
+		if newCategory != "" {
+			producto.Category = newCategory
+		}
 
-	// Guarda los cambios en la base de datos
-	if err := tx.Save(&producto).Error; err != nil {
-		// Ocurrió un error al guardar en la base de datos, realiza un rollback
-		tx.Rollback()
-		return producto, err
-	}
+		// Guarda los cambios
+		if err := tx.Products().Save(&producto); err != nil {
+			return err
+		}
 
-	// Confirma la transacción
-	tx.Commit()
+		return storeAudit(tx, audit, "Product", producto.ID, "update", before, producto)
+	})
 
 	// Devuelve el producto actualizado
-	return producto, nil
+	return producto, err
 }
 
-func DeleteProductByName(nameProduct string) error {
-	// Abre una transacción
-	tx := db.DB.Begin()
+// DeleteProductByName archiva el producto: deja de aparecer en el menú pero las comandas
+// antiguas lo siguen referenciando.
+func (s *Service) DeleteProductByName(audit models.AuditInfo, nameProduct string) error {
+	return s.store.Transaction(func(tx repository.Store) error {
+		// Busca el producto por nombre entre los que no están archivados
+		product, err := tx.Products().FindByName(nameProduct)
+		if err != nil {
+			return err
+		}
+		if product.ArchivedAt != nil {
+			return repository.ErrNotFound
+		}
+		if err := ownProduct(product, audit.BranchID); err != nil {
+			return err
+		}
+		before := snapshot(product)
 
-	// Maneja los errores de la transacción
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+		// Archiva el producto
+		now := time.Now()
+		product.ArchivedAt = &now
+		if err := tx.Products().Save(product); err != nil {
+			return err
 		}
-	}()
 
-	// Busca el producto por nombre
-	var product models.Product
-	if err := tx.Where("name = ?", nameProduct).First(&product).Error; err != nil {
-		tx.Rollback() // Deshace la transacción en caso de error
-		return err
-	}
+		return storeAudit(tx, audit, "Product", product.ID, "archive", before, product)
+	})
+}
 
-	// Elimina el producto
-	if err := tx.Delete(&product).Error; err != nil {
-		tx.Rollback() // Deshace la transacción en caso de error
-		return err
+// AddOrderItem agrega un item a la orden del usuario, en el tiempo course del menú (1 si es 0).
+// Con hold el item queda retenido hasta disparar su tiempo con FireCourse o FireItems; si no,
+// se envía a cocina de inmediato.
+func (s *Service) AddOrderItem(audit models.AuditInfo, userID uint, productID uint, quantity int, tableNumber int, course int, hold bool) (*models.OrderItem, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	if course < 0 {
+		return nil, errors.New("course must be greater than zero")
+	}
+	if course == 0 {
+		course = 1
 	}
 
-	// Confirma la transacción si no hay errores
-	tx.Commit()
-
-	return nil
-}
-
-// AddOrderItem agrega un item a la orden del usuario.
-func AddOrderItem(userID uint, productID uint, quantity int, tableNumber int) (*models.OrderItem, error) {
-	// Validar que el producto exista
-	var product models.Product
-	if err := db.DB.First(&product, productID).Error; err != nil {
+	// Validar que el producto exista, no esté archivado ni oculto en la sucursal, y tomar su precio en ella
+	product, err := s.store.Products().FindByID(productID)
+	if err == nil {
+		product, err = branchProduct(s.store, audit.BranchID, product)
+	}
+	if errors.Is(err, errNoBranchPrice) {
+		return nil, err
+	}
+	if err != nil || product.ArchivedAt != nil {
 		return nil, fmt.Errorf("product not found")
 	}
 
@@ -150,121 +187,101 @@ func AddOrderItem(userID uint, productID uint, quantity int, tableNumber int) (*
 		UserID:     userID,
 		ProductID:  productID,
 		Quantity:   quantity,
-		TotalPrice: product.Price * quantity,
+		TotalPrice: product.Price.Mul(quantity),
 		TableNumber: tableNumber, // Número de mesa
+		ProductName: product.Name,  // Copia del nombre al momento de agregarlo
+		UnitPrice:  product.Price,  // Copia del precio al momento de agregarlo
+		Allergens:  product.Allergens,   // Se copian para que cocina los vea en la comanda
+		DietaryTags: product.DietaryTags,
+		Estado:     models.OrderItemActivo,
+		Course:     course,
+		Held:       hold,
+	}
+	if !hold {
+		now := time.Now()
+		orderItem.FiredAt = &now
 	}
 
-	// Iniciar una transacción
-	tx := db.DB.Begin()
-
-	// Verificar si ya existe una Order pendiente para esa mesa
-	var existingOrder models.Order
-	if err := tx.Where("table_number = ? AND estado = ?", tableNumber, "Pendiente").First(&existingOrder).Error; err != nil {
-		if err.Error() == "record not found" {
-			// No existe una Order pendiente, entonces crear una nueva
-			order := models.Order{
-				UserID:      userID,
-				TableNumber: tableNumber,
-				OrderDate:   time.Now(),
-				Estado:      "Pendiente", // Estado inicial
-			}
-
-			// Guardar la Order en la base de datos
-			if err := tx.Create(&order).Error; err != nil {
-				tx.Rollback() // Revertir si ocurre un error
-				return nil, fmt.Errorf("failed to create order: %w", err)
-			}
-
-			// Asignar el OrderID a la OrderItem
-			orderItem.OrderID = &order.ID
+	var order *models.Order
+	err = s.store.Transaction(func(tx repository.Store) error {
+		// Buscar la Order pendiente de la mesa, o crear una nueva si no existe
+		var created bool
+		var err error
+		order, created, err = findOrCreateOpenOrder(tx, tableNumber, userID, branchCurrency(tx, audit.BranchID).Code)
+		if err != nil {
+			return err
+		}
 
-			// Guardar el OrderItem en la base de datos
-			if err := tx.Create(&orderItem).Error; err != nil {
-				tx.Rollback() // Revertir si ocurre un error
-				return nil, fmt.Errorf("failed to create order item: %w", err)
-			}
+		// Asignar el OrderID a la OrderItem y guardarla
+		orderItem.OrderID = &order.ID
+		if err := tx.OrderItems().Create(&orderItem); err != nil {
+			return fmt.Errorf("failed to create order item: %w", err)
+		}
 
-			// Calcular el TotalAmount de la nueva Order sumando los TotalPrice de todos los OrderItems
-			var totalAmount int
-			if err := tx.Model(&models.OrderItem{}).Where("order_id = ?", order.ID).Select("sum(total_price)").Scan(&totalAmount).Error; err != nil {
-				tx.Rollback() // Revertir si ocurre un error
-				return nil, fmt.Errorf("failed to calculate total amount: %w", err)
-			}
+		// Actualizar el TotalAmount de la Order sumando los TotalPrice de todos los OrderItems
+		if err := recalculateOrderTotal(tx, order); err != nil {
+			return err
+		}
 
-			// Actualizar el TotalAmount de la Order
-			order.TotalAmount = totalAmount
-			if err := tx.Save(&order).Error; err != nil {
-				tx.Rollback() // Revertir si ocurre un error
-				return nil, fmt.Errorf("failed to update total amount: %w", err)
+		// Imprimir el ticket en la estación que lo prepara, salvo que quede retenido
+		if !hold {
+			if err := queueKitchenTickets(tx, order, []models.OrderItem{orderItem}); err != nil {
+				return err
 			}
-		} else {
-			tx.Rollback()
-			return nil, fmt.Errorf("failed to check for existing order: %w", err)
-		}
-	} else {
-		// Si ya existe una Order pendiente, asignamos el OrderID a la OrderItem
-		orderItem.OrderID = &existingOrder.ID
-
-		// Guardar el OrderItem en la base de datos
-		if err := tx.Create(&orderItem).Error; err != nil {
-			tx.Rollback() // Revertir si ocurre un error
-			return nil, fmt.Errorf("failed to create order item: %w", err)
 		}
 
-		// Calcular el TotalAmount de la Order existente sumando los TotalPrice de todos los OrderItems
-		var totalAmount int
-		if err := tx.Model(&models.OrderItem{}).Where("order_id = ?", existingOrder.ID).Select("sum(total_price)").Scan(&totalAmount).Error; err != nil {
-			tx.Rollback() // Revertir si ocurre un error
-			return nil, fmt.Errorf("failed to calculate total amount: %w", err)
+		if created {
+			if err := storeAudit(tx, audit, "Order", order.ID, "create", nil, orderSnapshot(order)); err != nil {
+				return err
+			}
 		}
 
-		// Actualizar el TotalAmount de la Order existente
-		existingOrder.TotalAmount = totalAmount
-		if err := tx.Save(&existingOrder).Error; err != nil {
-			tx.Rollback() // Revertir si ocurre un error
-			return nil, fmt.Errorf("failed to update total amount: %w", err)
-		}
+		return storeAudit(tx, audit, "OrderItem", orderItem.ID, "create", nil, orderItem)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Confirmar la transacción si todo salió bien
-	tx.Commit()
-
 	// Asegurarse de poblar el campo Product al devolver el OrderItem
-	if err := db.DB.Preload("Product").First(&orderItem, orderItem.ID).Error; err != nil {
+	item, err := s.store.OrderItems().FindByID(orderItem.ID)
+	if err != nil {
 		return nil, fmt.Errorf("failed to fetch related product details: %w", err)
 	}
 
+	// Avisar si el producto tiene alérgenos declarados por los comensales de la mesa
+	item.Warnings = allergyWarnings(order.GuestAllergies, product.Allergens)
+
 	// Retornar el OrderItem creado
-	return &orderItem, nil
+	return item, nil
 }
 
 
-func GetAllOrderItems() ([]models.OrderItem, error) {
+func GetAllOrderItems(branchID uint) ([]models.OrderItem, error) {
 	var orderItems []models.OrderItem
 
-	// Consulta para obtener todos los OrderItems y pre-cargar la relación Product
-	if err := db.DB.Preload("Product").Find(&orderItems).Error; err != nil {
+	// Consulta para obtener todos los OrderItems de la sucursal y pre-cargar la relación Product
+	if err := branchDB(branchID).Preload("Product").Find(&orderItems).Error; err != nil {
 		return nil, err
 	}
 
 	return orderItems, nil
 }
 
-func GetOrderItemsByUserID(userID uint) ([]models.OrderItem, error) {
+func GetOrderItemsByUserID(branchID uint, userID uint) ([]models.OrderItem, error) {
 	var orderItems []models.OrderItem
 
 	// Consulta para obtener los OrderItems por UserID y pre-cargar la relación Product
-	if err := db.DB.Where("user_id = ?", userID).Preload("Product").Find(&orderItems).Error; err != nil {
+	if err := branchDB(branchID).Where("user_id = ?", userID).Preload("Product").Find(&orderItems).Error; err != nil {
 		return nil, err
 	}
 
 	return orderItems, nil
 }
 
-func GetAllOrders() ([]models.Order, error) {
+func GetAllOrders(branchID uint) ([]models.Order, error) {
     var orders []models.Order
     // Utiliza Preload para cargar los OrderItems asociados a cada Order
-    err := db.DB.Preload("Items.Product").Find(&orders).Error // 'Items' es el campo que representa la relación en el modelo Order
+    err := branchDB(branchID).Preload("Items.Product").Find(&orders).Error // 'Items' es el campo que representa la relación en el modelo Order
     if err != nil {
         log.Println("Error fetching orders:", err)
         return nil, err
@@ -273,69 +290,83 @@ func GetAllOrders() ([]models.Order, error) {
 }
 
 
-// Actualiza el estado de una orden usando el número de mesa
-func UpdateOrderStatus(orderID uint, newStatus string) (*models.Order, error) {
-    var order models.Order
+// Actualiza el estado de una orden. Si version no es 0 debe coincidir con la versión actual de la orden.
+func (s *Service) UpdateOrderStatus(audit models.AuditInfo, orderID uint, newStatus string, version int) (*models.Order, error) {
+    var order *models.Order
 
-    // Buscar la orden por su ID
-    if err := db.DB.First(&order, orderID).Error; err != nil {
-        return nil, fmt.Errorf("Order not found: %v", err)
-    }
+    err := s.store.Transaction(func(tx repository.Store) error {
+        // Buscar la orden por su ID
+        var err error
+        order, err = tx.Orders().FindByID(orderID)
+        if err != nil {
+            return fmt.Errorf("Order not found: %v", err)
+        }
+        if err := checkVersion(order, version); err != nil {
+            return err
+        }
+        before := orderSnapshot(order)
 
-    // Actualizar el estado de la orden
-    order.Estado = newStatus
+        // Actualizar el estado de la orden
+        order.Estado = newStatus
 
-    // Guardar los cambios en la base de datos
-    if err := db.DB.Save(&order).Error; err != nil {
-        return nil, fmt.Errorf("Failed to update order status: %v", err)
+        // Guardar los cambios
+        if err := tx.Orders().Save(order); err != nil {
+            return fmt.Errorf("Failed to update order status: %w", err)
+        }
+
+        return storeAudit(tx, audit, "Order", order.ID, "update", before, orderSnapshot(order))
+    })
+    if err != nil {
+        return nil, err
     }
 
-    return &order, nil
+    return order, nil
 }
 
-// Elimina un OrderItem de una orden asignada
-// Elimina un OrderItem de una orden asignada y también elimina la referencia de la lista de OrderItems de la orden
-func DeleteOrderItem(orderItemID uint) (*models.OrderItem, error) {
-    var orderItem models.OrderItem
-
-    // Buscar el OrderItem por su ID
-    if err := db.DB.First(&orderItem, orderItemID).Error; err != nil {
-        return nil, fmt.Errorf("OrderItem not found: %v", err)
-    }
-
-    // Buscar la orden asociada al OrderItem
-    var order models.Order
-    if err := db.DB.First(&order, orderItem.OrderID).Error; err != nil {
-        return nil, fmt.Errorf("Order not found: %v", err)
-    }
+// Elimina un OrderItem de una orden asignada y también elimina la referencia de la lista de OrderItems de la orden.
+// Solo se pueden eliminar los items retenidos de una comanda pendiente; los que ya se enviaron a
+// cocina se anulan con VoidOrderItem, que deja el motivo y la aprobación del encargado.
+func (s *Service) DeleteOrderItem(audit models.AuditInfo, orderItemID uint) (*models.OrderItem, error) {
+    var orderItem *models.OrderItem
+
+    err := s.store.Transaction(func(tx repository.Store) error {
+        // Buscar el OrderItem por su ID
+        var err error
+        orderItem, err = tx.OrderItems().FindByID(orderItemID)
+        if err != nil {
+            return fmt.Errorf("OrderItem not found: %v", err)
+        }
 
-    // Eliminar el OrderItem de la base de datos
-    if err := db.DB.Delete(&orderItem).Error; err != nil {
-        return nil, fmt.Errorf("Failed to delete OrderItem: %v", err)
-    }
+        // Buscar la orden asociada al OrderItem
+        if orderItem.OrderID == nil {
+            return errors.New("Order not found: order item has no order")
+        }
+        order, err := tx.Orders().FindByID(*orderItem.OrderID)
+        if err != nil {
+            return fmt.Errorf("Order not found: %v", err)
+        }
+        if order.Estado != "Pendiente" {
+            return errors.New("only items of pending orders can be deleted")
+        }
+        if orderItem.FiredAt != nil {
+            return errors.New("order item was already sent to the kitchen; void it instead")
+        }
 
-    // Actualizar la lista de items de la orden eliminando el item eliminado
-    for i, item := range order.Items {
-        if item.ID == orderItem.ID {
-            order.Items = append(order.Items[:i], order.Items[i+1:]...) // Eliminar el item de la lista
-            break
+        // Eliminar el OrderItem
+        if err := tx.OrderItems().Delete(orderItem); err != nil {
+            return fmt.Errorf("Failed to delete OrderItem: %v", err)
         }
-    }
 
-    // Descontar el precio del OrderItem eliminado del total de la orden
-    order.TotalAmount -= orderItem.TotalPrice
+        if err := storeAudit(tx, audit, "OrderItem", orderItem.ID, "delete", snapshot(orderItem), nil); err != nil {
+            return err
+        }
 
-    // Guardar la actualización de la orden
-    if err := db.DB.Save(&order).Error; err != nil {
-        return nil, fmt.Errorf("Failed to update order total: %v", err)
+        // Recalcular el total con los items que quedan (los anulados ya no sumaban)
+        return recalculateOrderTotal(tx, order)
+    })
+    if err != nil {
+        return nil, err
     }
 
-    return &orderItem, nil
+    return orderItem, nil
 }
-
-
-
-
-
-
-
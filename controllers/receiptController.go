@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"time"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/receipt"
+)
+
+// GetReceipt genera la pre-cuenta o el comprobante de una comanda de la sucursal. La comanda se
+// busca por ID o, si orderID es 0, como la pendiente de la mesa. format es text, escpos o pdf
+// (text por defecto) y width el ancho del papel, 58mm u 80mm (80mm por defecto).
+func (s *Service) GetReceipt(branchID uint, orderID uint, tableNumber int, format string, width string) (*models.Receipt, error) {
+	if format == "" {
+		format = "text"
+	}
+	if width == "" {
+		width = "80mm"
+	}
+
+	var order *models.Order
+	var err error
+	if orderID != 0 {
+		order, err = s.GetOrderByID(orderID)
+	} else {
+		order, err = s.GetOrderByTable(tableNumber)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.store.Payments().FindByOrder(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	var branchName string
+	if branch, err := s.store.Branches().FindByID(branchID); err == nil {
+		branchName = branch.Name
+	}
+
+	doc := receipt.FromOrder(*order, payments, branchName, currencyByCode(order.Currency), db.Receipt, db.Rounding, time.Now())
+	content, err := receipt.Render(doc, format, width)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Receipt{
+		OrderID:     order.ID,
+		Format:      format,
+		Width:       width,
+		ContentType: receipt.Formats[format],
+		Content:     string(content),
+	}
+	if format != "text" {
+		result.Encoding = "base64"
+		result.Content = base64.StdEncoding.EncodeToString(content)
+	}
+	return result, nil
+}
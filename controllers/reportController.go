@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// Estados de comanda que cuentan como venta. Las comandas pendientes no entran hasta que se
+// pagan, para que las cifras del día no cambien mientras las mesas siguen comiendo.
+var salesStates = []string{"Pagada"}
+
+// Intervalos aceptados por date_trunc para agrupar las ventas.
+var reportBuckets = map[string]bool{"hour": true, "day": true, "week": true}
+
+// checkReportRange valida el periodo [from, to) de los reportes: el fin debe ser posterior al inicio.
+func checkReportRange(from time.Time, to time.Time) error {
+	if !to.After(from) {
+		return errors.New("the end of the range must be after the start")
+	}
+	return nil
+}
+
+// GetSalesReport agrupa comandas, comensales, venta y ticket promedio de la sucursal por hora, día o semana.
+// Solo cuenta las comandas pagadas.
+func GetSalesReport(branchID uint, from time.Time, to time.Time, bucket string) ([]models.SalesBucket, error) {
+	if !reportBuckets[bucket] {
+		return nil, fmt.Errorf("invalid bucket %q, use hour, day or week", bucket)
+	}
+	if err := checkReportRange(from, to); err != nil {
+		return nil, err
+	}
+
+	var report []models.SalesBucket
+	err := branchDB(branchID).Model(&models.Order{}).
+		Select("date_trunc(?, order_date) AS bucket, count(*) AS orders, coalesce(sum(covers), 0) AS covers, coalesce(sum(total_amount), 0) AS revenue", bucket).
+		Where("order_date >= ? AND order_date < ? AND estado IN ?", from, to, salesStates).
+		Group("bucket").
+		Order("bucket").
+		Scan(&report).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range report {
+		if report[i].Orders > 0 {
+			report[i].AverageTicket = report[i].Revenue.Div(report[i].Orders, db.Rounding)
+		}
+	}
+	return report, nil
+}
+
+// Nombre con que se vendió un item: el guardado en el item o, si no lo tiene, el actual del producto.
+const soldName = "COALESCE(NULLIF(order_items.product_name, ''), products.name)"
+
+// GetProductSales suma unidades y venta por producto o por categoría, de mayor a menor cantidad vendida.
+// Solo cuenta los items activos (no anulados ni cortesías) de las comandas pagadas de la sucursal.
+// El nombre es el que tenía el producto al venderse; los items anteriores a ese registro usan el
+// actual. limit <= 0 no limita los resultados.
+func GetProductSales(branchID uint, from time.Time, to time.Time, groupBy string, limit int) ([]models.ProductSales, error) {
+	if err := checkReportRange(from, to); err != nil {
+		return nil, err
+	}
+
+	query := branchDB(branchID).Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("order_items.estado = ?", models.OrderItemActivo).
+		Where("orders.order_date >= ? AND orders.order_date < ? AND orders.estado IN ?", from, to, salesStates)
+
+	switch groupBy {
+	case "", "product":
+		query = query.
+			Select("order_items.product_id, " + soldName + " AS name, products.category, sum(order_items.quantity) AS units, sum(order_items.total_price) AS revenue").
+			Group("order_items.product_id, " + soldName + ", products.category")
+	case "category":
+		query = query.
+			Select("products.category, sum(order_items.quantity) AS units, sum(order_items.total_price) AS revenue").
+			Group("products.category")
+	default:
+		return nil, fmt.Errorf("invalid group_by %q, use product or category", groupBy)
+	}
+
+	query = query.Order("units DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var sales []models.ProductSales
+	if err := query.Scan(&sales).Error; err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// GetTop3PopularProducts devuelve los tres productos más vendidos de la sucursal en los últimos 30 días.
+func GetTop3PopularProducts(branchID uint) ([]models.ProductSales, error) {
+	now := time.Now()
+	return GetProductSales(branchID, now.AddDate(0, 0, -30), now, "product", 3)
+}
+
+// SetOrderCovers registra la cantidad de comensales de una comanda. Si version no es 0 debe
+// coincidir con la versión actual de la comanda.
+func (s *Service) SetOrderCovers(audit models.AuditInfo, orderID uint, covers int, version int) (*models.Order, error) {
+	if covers < 0 {
+		return nil, errors.New("covers cannot be negative")
+	}
+
+	var order *models.Order
+	err := s.store.Transaction(func(tx repository.Store) error {
+		var err error
+		order, err = tx.Orders().FindByID(orderID)
+		if err != nil {
+			return fmt.Errorf("Order not found: %v", err)
+		}
+		if err := checkVersion(order, version); err != nil {
+			return err
+		}
+		before := orderSnapshot(order)
+
+		order.Covers = covers
+		if err := tx.Orders().Save(order); err != nil {
+			return fmt.Errorf("failed to update covers: %w", err)
+		}
+
+		return storeAudit(tx, audit, "Order", order.ID, "update", before, orderSnapshot(order))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"gorm.io/gorm"
+)
+
+// Similitud mínima de trigramas para aceptar un nombre con errores de tipeo.
+const searchSimilarityThreshold = 0.3
+
+// prefixTSQuery arma una consulta de texto completo donde cada palabra se busca como prefijo,
+// así "pisc sou" encuentra "pisco sour". Se descartan los caracteres que no son letras ni dígitos.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// SearchProducts busca productos por nombre y descripción, ordenados por relevancia. Combina
+// la búsqueda de texto completo en español (sin acentos, con raíces y prefijos) con la similitud
+// de trigramas sobre el nombre para tolerar errores de tipeo. Por defecto excluye los archivados.
+// La búsqueda se hace sobre los textos en español; los resultados se devuelven en el idioma pedido.
+// excludeAllergens y dietaryTags filtran igual que en ListProducts. Solo busca en el menú de la sucursal.
+func SearchProducts(branchID uint, text string, limit int, includeArchived bool, locale string, excludeAllergens []string, dietaryTags []string) ([]models.ProductSearchResult, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("search text is required")
+	}
+	if limit <= 0 || limit > maxPageSize {
+		limit = 20
+	}
+
+	// Las expresiones son las de los índices de la migración 0005, para que el planificador los use
+	document := "to_tsvector('spanish', f_unaccent(name || ' ' || description))"
+	query := "to_tsquery('spanish', f_unaccent(?))"
+	name := "f_unaccent(lower(name))"
+	term := "f_unaccent(lower(?))"
+	tsquery := prefixTSQuery(text)
+
+	// Cada parte de la unión usa su índice: un OR entre las dos condiciones no usa ninguno. <% es
+	// la forma indexable de word_similarity y toma el umbral de pg_trgm.word_similarity_threshold.
+	matches := "SELECT id FROM products WHERE " + term + " <% " + name
+	matchArgs := []interface{}{text}
+	rank := "word_similarity(" + term + ", " + name + ")"
+	rankArgs := []interface{}{text}
+	// Sin palabras válidas solo se usa la similitud de trigramas
+	if tsquery != "" {
+		matches = "SELECT id FROM products WHERE " + document + " @@ " + query + " UNION " + matches
+		matchArgs = append([]interface{}{tsquery}, matchArgs...)
+		rank = "ts_rank(" + document + ", " + query + ") + " + rank
+		rankArgs = append([]interface{}{tsquery}, rankArgs...)
+	}
+
+	tx := branchDB(branchID)
+	var results []models.ProductSearchResult
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", searchSimilarityThreshold)).Error; err != nil {
+			return err
+		}
+
+		sql := tx.Model(&models.Product{}).
+			Select("products.*, "+rank+" AS rank", rankArgs...).
+			Where("products.id IN ("+matches+")", matchArgs...)
+		if !includeArchived {
+			sql = sql.Where("archived_at IS NULL")
+		}
+		sql, err := applyDietaryFilter(withoutHidden(sql, branchID), excludeAllergens, dietaryTags)
+		if err != nil {
+			return err
+		}
+		return sql.Order("rank DESC, name").Limit(limit).Scan(&results).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]models.Product, len(results))
+	for i := range results {
+		products[i] = results[i].Product
+	}
+	if err := applyOverrides(tx, products); err != nil {
+		return nil, err
+	}
+	if err := localizeProducts(repository.NewGormStore(tx), products, locale); err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Product = products[i]
+	}
+	return results, nil
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+	"gorm.io/gorm"
+)
+
+// Service reúne la lógica de productos, comandas e items sobre un repository.Store, de modo
+// que se pueda probar con repository.NewMemoryStore sin una base de datos.
+type Service struct {
+	store repository.Store
+}
+
+// NewService crea un Service que usa el Store dado.
+func NewService(store repository.Store) *Service {
+	return &Service{store: store}
+}
+
+// Store usado por las funciones del paquete; si es nil se usa la conexión global a Postgres.
+var defaultStore repository.Store
+
+// UseStore cambia el Store de las funciones del paquete, por ejemplo por uno en memoria
+// para ejecutar el handler sin base de datos.
+func UseStore(store repository.Store) {
+	defaultStore = store
+}
+
+// defaultService devuelve el Service limitado a la sucursal.
+func defaultService(branchID uint) *Service {
+	if defaultStore != nil {
+		return NewService(defaultStore.Branch(branchID))
+	}
+	return NewService(repository.NewGormStore(db.DB).Branch(branchID))
+}
+
+// branchDB devuelve la conexión a Postgres limitada a la sucursal, para los controladores que
+// usan GORM directamente.
+func branchDB(branchID uint) *gorm.DB {
+	return tenant.Scope(db.DB, branchID)
+}
+
+// Funciones que usa el handler; delegan en el Service sobre Postgres. Las escrituras usan la
+// sucursal de audit y las lecturas reciben la sucursal como primer parámetro.
+
+func CreateProduct(audit models.AuditInfo, name string, description string, price money.Amount, category string) (models.Product, error) {
+	return defaultService(audit.BranchID).CreateProduct(audit, name, description, price, category)
+}
+
+func UpdateProduct(audit models.AuditInfo, productoIngresado string, newName string, newPrice money.Amount, newDescription string, newCategory string) (models.Product, error) {
+	return defaultService(audit.BranchID).UpdateProduct(audit, productoIngresado, newName, newPrice, newDescription, newCategory)
+}
+
+func DeleteProductByName(audit models.AuditInfo, nameProduct string) error {
+	return defaultService(audit.BranchID).DeleteProductByName(audit, nameProduct)
+}
+
+func RestoreProduct(audit models.AuditInfo, name string) (models.Product, error) {
+	return defaultService(audit.BranchID).RestoreProduct(audit, name)
+}
+
+func AddOrderItem(audit models.AuditInfo, userID uint, productID uint, quantity int, tableNumber int, course int, hold bool) (*models.OrderItem, error) {
+	return defaultService(audit.BranchID).AddOrderItem(audit, userID, productID, quantity, tableNumber, course, hold)
+}
+
+func UpdateOrderStatus(audit models.AuditInfo, orderID uint, newStatus string, version int) (*models.Order, error) {
+	return defaultService(audit.BranchID).UpdateOrderStatus(audit, orderID, newStatus, version)
+}
+
+func DeleteOrderItem(audit models.AuditInfo, orderItemID uint) (*models.OrderItem, error) {
+	return defaultService(audit.BranchID).DeleteOrderItem(audit, orderItemID)
+}
+
+func VoidOrderItem(audit models.AuditInfo, orderItemID uint, reasonCode string, approval models.Approval, wasPrepared bool) (*models.OrderItem, error) {
+	return defaultService(audit.BranchID).VoidOrderItem(audit, orderItemID, reasonCode, approval, wasPrepared)
+}
+
+func CompOrderItem(audit models.AuditInfo, orderItemID uint, reasonCode string, approval models.Approval, wasPrepared bool) (*models.OrderItem, error) {
+	return defaultService(audit.BranchID).CompOrderItem(audit, orderItemID, reasonCode, approval, wasPrepared)
+}
+
+func UpdateOrderItem(audit models.AuditInfo, orderItemID uint, quantity int, notes *string, modifiers []string, force bool, approval models.Approval) (*models.OrderItem, error) {
+	return defaultService(audit.BranchID).UpdateOrderItem(audit, orderItemID, quantity, notes, modifiers, force, approval)
+}
+
+func UpdateKitchenStatus(audit models.AuditInfo, orderItemID uint, status string) (*models.OrderItem, error) {
+	return defaultService(audit.BranchID).UpdateKitchenStatus(audit, orderItemID, status)
+}
+
+func FireCourse(audit models.AuditInfo, tableNumber int, course int) ([]models.OrderItem, error) {
+	return defaultService(audit.BranchID).FireCourse(audit, tableNumber, course)
+}
+
+func FireItems(audit models.AuditInfo, orderItemIDs []uint) ([]models.OrderItem, error) {
+	return defaultService(audit.BranchID).FireItems(audit, orderItemIDs)
+}
+
+func GetKitchenItems(branchID uint, station string) ([]models.OrderItem, error) {
+	return defaultService(branchID).GetKitchenItems(station)
+}
+
+func TransferOrder(audit models.AuditInfo, fromTable int, toTable int) (*models.Order, error) {
+	return defaultService(audit.BranchID).TransferOrder(audit, fromTable, toTable)
+}
+
+func MergeOrders(audit models.AuditInfo, sourceTable int, targetTable int) (*models.Order, error) {
+	return defaultService(audit.BranchID).MergeOrders(audit, sourceTable, targetTable)
+}
+
+func SplitOrder(audit models.AuditInfo, orderID uint, itemIDs []uint, toTable int) (*models.Order, error) {
+	return defaultService(audit.BranchID).SplitOrder(audit, orderID, itemIDs, toTable)
+}
+
+func SetOrderAllergies(audit models.AuditInfo, tableNumber int, allergies []string, version int) (*models.Order, error) {
+	return defaultService(audit.BranchID).SetOrderAllergies(audit, tableNumber, allergies, version)
+}
+
+func GetOrderByID(branchID uint, orderID uint) (*models.Order, error) {
+	return defaultService(branchID).GetOrderByID(orderID)
+}
+
+func GetOrderByTable(branchID uint, tableNumber int) (*models.Order, error) {
+	return defaultService(branchID).GetOrderByTable(tableNumber)
+}
+
+func GetActiveOrders(branchID uint) ([]models.Order, error) {
+	return defaultService(branchID).GetActiveOrders()
+}
+
+func GetOrdersByUser(branchID uint, userID uint) ([]models.Order, error) {
+	return defaultService(branchID).GetOrdersByUser(userID)
+}
+
+func CreateBranch(audit models.AuditInfo, name string, currency string) (*models.Branch, error) {
+	return defaultService(audit.BranchID).CreateBranch(audit, name, currency)
+}
+
+func GetBranches(branchID uint) ([]models.Branch, error) {
+	return defaultService(branchID).GetBranches(branchID)
+}
+
+func SetProductOverride(audit models.AuditInfo, productName string, price *money.Amount, hidden bool) (*models.ProductOverride, error) {
+	return defaultService(audit.BranchID).SetProductOverride(audit, productName, price, hidden)
+}
+
+func DeleteProductOverride(audit models.AuditInfo, productName string) (*models.ProductOverride, error) {
+	return defaultService(audit.BranchID).DeleteProductOverride(audit, productName)
+}
+
+func GetReceipt(branchID uint, orderID uint, tableNumber int, format string, width string) (*models.Receipt, error) {
+	return defaultService(branchID).GetReceipt(branchID, orderID, tableNumber, format, width)
+}
+
+func GetPrintJobs(branchID uint, estado string) ([]models.PrintJob, error) {
+	return defaultService(branchID).GetPrintJobs(estado)
+}
+
+func RetryPrintJob(audit models.AuditInfo, jobID uint) (*models.PrintJob, error) {
+	return defaultService(audit.BranchID).RetryPrintJob(audit, jobID)
+}
+
+func ReprintPrintJob(audit models.AuditInfo, jobID uint, station string) (*models.PrintJob, error) {
+	return defaultService(audit.BranchID).ReprintPrintJob(audit, jobID, station)
+}
+
+func SetProductDietary(audit models.AuditInfo, name string, allergens []string, dietaryTags []string) (models.Product, error) {
+	return defaultService(audit.BranchID).SetProductDietary(audit, name, allergens, dietaryTags)
+}
+
+func ImportProducts(audit models.AuditInfo, format string, content []byte, dryRun bool) (*models.ImportResult, error) {
+	return defaultService(audit.BranchID).ImportProducts(audit, format, content, dryRun)
+}
+
+func ImportCatalog(audit models.AuditInfo, rows []models.CatalogRow, dryRun bool) (*models.ImportResult, error) {
+	return defaultService(audit.BranchID).ImportCatalog(audit, rows, dryRun)
+}
+
+func SetProductTranslation(audit models.AuditInfo, productName string, locale string, name string, description string) (*models.ProductTranslation, error) {
+	return defaultService(audit.BranchID).SetProductTranslation(audit, productName, locale, name, description)
+}
+
+func DeleteProductTranslation(audit models.AuditInfo, productName string, locale string) (*models.ProductTranslation, error) {
+	return defaultService(audit.BranchID).DeleteProductTranslation(audit, productName, locale)
+}
+
+func GetProductTranslations(branchID uint, productName string) ([]models.ProductTranslation, error) {
+	return defaultService(branchID).GetProductTranslations(productName)
+}
+
+func SetCategoryTranslation(audit models.AuditInfo, category string, locale string, name string) (*models.CategoryTranslation, error) {
+	return defaultService(audit.BranchID).SetCategoryTranslation(audit, category, locale, name)
+}
+
+func AddProductImage(audit models.AuditInfo, productName string, data []byte) (*models.ProductImage, error) {
+	return defaultService(audit.BranchID).AddProductImage(audit, productName, data)
+}
+
+func AddProductImageBase64(audit models.AuditInfo, productName string, encoded string) (*models.ProductImage, error) {
+	return defaultService(audit.BranchID).AddProductImageBase64(audit, productName, encoded)
+}
+
+func DeleteProductImage(audit models.AuditInfo, imageID uint) (*models.ProductImage, error) {
+	return defaultService(audit.BranchID).DeleteProductImage(audit, imageID)
+}
+
+func GetProductImages(branchID uint, productName string) ([]models.ProductImage, error) {
+	return defaultService(branchID).GetProductImages(productName)
+}
+
+func SetOrderCovers(audit models.AuditInfo, orderID uint, covers int, version int) (*models.Order, error) {
+	return defaultService(audit.BranchID).SetOrderCovers(audit, orderID, covers, version)
+}
+
+func OpenShift(audit models.AuditInfo, startingCash money.Amount) (*models.Shift, error) {
+	return defaultService(audit.BranchID).OpenShift(audit, startingCash)
+}
+
+func GetCurrentShift(branchID uint) (*models.Shift, error) {
+	return defaultService(branchID).GetCurrentShift()
+}
+
+func RegisterPayment(audit models.AuditInfo, orderID uint, tender string, amount money.Amount, tip money.Amount) (*models.Payment, error) {
+	return defaultService(audit.BranchID).RegisterPayment(audit, orderID, tender, amount, tip)
+}
+
+func CloseShift(audit models.AuditInfo, force bool) (*models.ZReport, error) {
+	return defaultService(audit.BranchID).CloseShift(audit, force)
+}
+
+func GetZReport(branchID uint, shiftID uint) (*models.ZReport, error) {
+	return defaultService(branchID).GetZReport(shiftID)
+}
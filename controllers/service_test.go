@@ -0,0 +1,437 @@
+package controllers_test
+
+import (
+	"testing"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/controllers"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+var (
+	mesero    = models.AuditInfo{UserID: 3, Role: "mesero", BranchID: 1, Pattern: "TEST"}
+	encargado = models.Approval{UserID: 9, Role: "encargado"}
+)
+
+// newService crea un Service sobre un Store en memoria de la sucursal 1 con dos productos:
+// Lomo (1200) y Jugo (3500).
+func newService(t *testing.T) (*controllers.Service, repository.Store) {
+	t.Helper()
+	store := repository.NewMemoryStore().Branch(1)
+	service := controllers.NewService(store)
+	for _, p := range []struct {
+		name  string
+		price money.Amount
+	}{{"Lomo", 1200}, {"Jugo", 3500}} {
+		if _, err := service.CreateProduct(mesero, p.name, p.name, p.price, "fondos"); err != nil {
+			t.Fatalf("CreateProduct(%s): %v", p.name, err)
+		}
+	}
+	return service, store
+}
+
+// addItem agrega quantity unidades del producto a la mesa y devuelve el item.
+func addItem(t *testing.T, service *controllers.Service, productID uint, quantity int, table int) *models.OrderItem {
+	t.Helper()
+	item, err := service.AddOrderItem(mesero, mesero.UserID, productID, quantity, table, 0, false)
+	if err != nil {
+		t.Fatalf("AddOrderItem(%d, %d, mesa %d): %v", productID, quantity, table, err)
+	}
+	return item
+}
+
+// orderOf devuelve la comanda pendiente de la mesa.
+func orderOf(t *testing.T, service *controllers.Service, table int) *models.Order {
+	t.Helper()
+	order, err := service.GetOrderByTable(table)
+	if err != nil {
+		t.Fatalf("GetOrderByTable(%d): %v", table, err)
+	}
+	return order
+}
+
+func assertTotal(t *testing.T, order *models.Order, want money.Amount) {
+	t.Helper()
+	if order.TotalAmount != want {
+		t.Errorf("order %d total = %d, want %d", order.ID, order.TotalAmount, want)
+	}
+}
+
+func TestAddOrderItemRecalculatesTotal(t *testing.T) {
+	service, _ := newService(t)
+	first := addItem(t, service, 1, 2, 5)
+	second := addItem(t, service, 2, 1, 5)
+
+	if *first.OrderID != *second.OrderID {
+		t.Fatalf("items of the same table went to orders %d and %d", *first.OrderID, *second.OrderID)
+	}
+	if first.TotalPrice != 2400 || first.UnitPrice != 1200 || first.ProductName != "Lomo" {
+		t.Errorf("item = %+v, want unit price 1200, total 2400 and name Lomo", first)
+	}
+	assertTotal(t, orderOf(t, service, 5), 5900)
+
+	for _, quantity := range []int{0, -1} {
+		if _, err := service.AddOrderItem(mesero, mesero.UserID, 1, quantity, 5, 0, false); err == nil {
+			t.Errorf("quantity %d was accepted", quantity)
+		}
+	}
+	assertTotal(t, orderOf(t, service, 5), 5900)
+}
+
+func TestTransferOrder(t *testing.T) {
+	service, _ := newService(t)
+	addItem(t, service, 1, 1, 5)
+	addItem(t, service, 2, 1, 7)
+
+	if _, err := service.TransferOrder(mesero, 5, 7); err == nil {
+		t.Fatal("transfer to a table with an open order succeeded")
+	}
+
+	order, err := service.TransferOrder(mesero, 5, 6)
+	if err != nil {
+		t.Fatalf("TransferOrder: %v", err)
+	}
+	if order.TableNumber != 6 {
+		t.Errorf("table = %d, want 6", order.TableNumber)
+	}
+	for _, item := range order.Items {
+		if item.TableNumber != 6 {
+			t.Errorf("item %d table = %d, want 6", item.ID, item.TableNumber)
+		}
+	}
+	if _, err := service.GetOrderByTable(5); err == nil {
+		t.Error("table 5 still has an open order")
+	}
+	assertTotal(t, order, 1200)
+}
+
+func TestMergeOrders(t *testing.T) {
+	service, _ := newService(t)
+	source := addItem(t, service, 1, 1, 5)
+	addItem(t, service, 2, 1, 6)
+
+	target, err := service.MergeOrders(mesero, 5, 6)
+	if err != nil {
+		t.Fatalf("MergeOrders: %v", err)
+	}
+	if len(target.Items) != 2 {
+		t.Errorf("target has %d items, want 2", len(target.Items))
+	}
+	assertTotal(t, target, 4700)
+
+	merged, err := service.GetOrderByID(*source.OrderID)
+	if err != nil {
+		t.Fatalf("GetOrderByID: %v", err)
+	}
+	if merged.Estado != "Fusionada" || len(merged.Items) != 0 {
+		t.Errorf("source order = %s with %d items, want Fusionada with none", merged.Estado, len(merged.Items))
+	}
+	assertTotal(t, merged, 0)
+}
+
+func TestSplitOrder(t *testing.T) {
+	service, _ := newService(t)
+	addItem(t, service, 1, 1, 5)
+	moved := addItem(t, service, 2, 2, 5)
+	other := addItem(t, service, 1, 1, 8)
+
+	if _, err := service.SplitOrder(mesero, *moved.OrderID, []uint{other.ID}, 6); err == nil {
+		t.Fatal("split with an item of another order succeeded")
+	}
+	if _, err := service.SplitOrder(mesero, *moved.OrderID, nil, 6); err == nil {
+		t.Fatal("split without items succeeded")
+	}
+
+	target, err := service.SplitOrder(mesero, *moved.OrderID, []uint{moved.ID}, 6)
+	if err != nil {
+		t.Fatalf("SplitOrder: %v", err)
+	}
+	if target.TableNumber != 6 || len(target.Items) != 1 || target.Items[0].ID != moved.ID {
+		t.Errorf("target = table %d with %d items, want table 6 with item %d", target.TableNumber, len(target.Items), moved.ID)
+	}
+	assertTotal(t, target, 7000)
+	assertTotal(t, orderOf(t, service, 5), 1200)
+}
+
+func TestVoidOrderItem(t *testing.T) {
+	service, _ := newService(t)
+	kept := addItem(t, service, 1, 1, 5)
+	voided := addItem(t, service, 2, 1, 5)
+
+	for name, tc := range map[string]struct {
+		audit    models.AuditInfo
+		approval models.Approval
+		reason   string
+	}{
+		"sin encargado":      {mesero, models.Approval{}, "CLIENTE_CAMBIO"},
+		"aprueba un mesero":  {mesero, models.Approval{UserID: 5, Role: "mesero"}, "CLIENTE_CAMBIO"},
+		"sin usuario":        {models.AuditInfo{BranchID: 1}, encargado, "CLIENTE_CAMBIO"},
+		"motivo desconocido": {mesero, encargado, "PORQUE_SI"},
+	} {
+		if _, err := service.VoidOrderItem(tc.audit, voided.ID, tc.reason, tc.approval, false); err == nil {
+			t.Errorf("%s: void succeeded", name)
+		}
+	}
+
+	item, err := service.VoidOrderItem(mesero, voided.ID, "CLIENTE_CAMBIO", encargado, true)
+	if err != nil {
+		t.Fatalf("VoidOrderItem: %v", err)
+	}
+	if item.Estado != models.OrderItemAnulado || !item.WasPrepared {
+		t.Errorf("item = %s (prepared %v), want Anulado and prepared", item.Estado, item.WasPrepared)
+	}
+	if item.VoidedBy == nil || *item.VoidedBy != mesero.UserID || item.ApprovedBy == nil || *item.ApprovedBy != encargado.UserID {
+		t.Errorf("voided by %v approved by %v, want %d and %d", item.VoidedBy, item.ApprovedBy, mesero.UserID, encargado.UserID)
+	}
+	assertTotal(t, orderOf(t, service, 5), kept.TotalPrice)
+
+	if _, err := service.VoidOrderItem(mesero, voided.ID, "CLIENTE_CAMBIO", encargado, false); err == nil {
+		t.Error("voiding an already voided item succeeded")
+	}
+}
+
+func TestCompOrderItem(t *testing.T) {
+	service, _ := newService(t)
+	kept := addItem(t, service, 2, 1, 5)
+	comped := addItem(t, service, 1, 2, 5)
+
+	// Un encargado puede aprobar su propia cortesía
+	manager := models.AuditInfo{UserID: encargado.UserID, Role: encargado.Role, BranchID: 1, Pattern: "TEST"}
+	item, err := service.CompOrderItem(manager, comped.ID, "CORTESIA_CASA", encargado, false)
+	if err != nil {
+		t.Fatalf("CompOrderItem: %v", err)
+	}
+	if item.Estado != models.OrderItemCortesia {
+		t.Errorf("item = %s, want Cortesia", item.Estado)
+	}
+	assertTotal(t, orderOf(t, service, 5), kept.TotalPrice)
+}
+
+func TestUpdateOrderItem(t *testing.T) {
+	service, _ := newService(t)
+	item := addItem(t, service, 1, 1, 5)
+	addItem(t, service, 2, 1, 5)
+
+	notes := "sin sal"
+	updated, err := service.UpdateOrderItem(mesero, item.ID, 3, &notes, []string{"bien cocido"}, false, models.Approval{})
+	if err != nil {
+		t.Fatalf("UpdateOrderItem: %v", err)
+	}
+	if updated.Quantity != 3 || updated.TotalPrice != 3600 || updated.Notes != notes || len(updated.Modifiers) != 1 {
+		t.Errorf("item = %+v, want quantity 3, total 3600, notes and one modifier", updated)
+	}
+	assertTotal(t, orderOf(t, service, 5), 7100)
+
+	if _, err := service.UpdateOrderItem(mesero, item.ID, 0, nil, nil, false, models.Approval{}); err == nil {
+		t.Error("quantity 0 was accepted")
+	}
+
+	if _, err := service.UpdateKitchenStatus(mesero, item.ID, models.KitchenEnPreparacion); err != nil {
+		t.Fatalf("UpdateKitchenStatus: %v", err)
+	}
+	if _, err := service.UpdateOrderItem(mesero, item.ID, 2, nil, nil, false, encargado); err == nil {
+		t.Error("item in preparation changed without force")
+	}
+	if _, err := service.UpdateOrderItem(mesero, item.ID, 2, nil, nil, true, models.Approval{UserID: 5, Role: "mesero"}); err == nil {
+		t.Error("item in preparation changed with a waiter's approval")
+	}
+	if _, err := service.UpdateOrderItem(mesero, item.ID, 2, nil, nil, true, encargado); err != nil {
+		t.Fatalf("forced UpdateOrderItem: %v", err)
+	}
+	assertTotal(t, orderOf(t, service, 5), 5900)
+}
+
+func TestDeleteOrderItem(t *testing.T) {
+	service, _ := newService(t)
+	fired := addItem(t, service, 1, 1, 5)
+	held, err := service.AddOrderItem(mesero, mesero.UserID, 2, 1, 5, 2, true)
+	if err != nil {
+		t.Fatalf("AddOrderItem: %v", err)
+	}
+
+	if _, err := service.DeleteOrderItem(mesero, fired.ID); err == nil {
+		t.Error("an item sent to the kitchen was deleted")
+	}
+	if _, err := service.DeleteOrderItem(mesero, held.ID); err != nil {
+		t.Fatalf("DeleteOrderItem: %v", err)
+	}
+	order := orderOf(t, service, 5)
+	if len(order.Items) != 1 {
+		t.Errorf("order has %d items, want 1", len(order.Items))
+	}
+	assertTotal(t, order, 1200)
+
+	held, err = service.AddOrderItem(mesero, mesero.UserID, 2, 1, 5, 2, true)
+	if err != nil {
+		t.Fatalf("AddOrderItem: %v", err)
+	}
+	if _, err := service.UpdateOrderStatus(mesero, order.ID, "Pagada", 0); err != nil {
+		t.Fatalf("UpdateOrderStatus: %v", err)
+	}
+	if _, err := service.DeleteOrderItem(mesero, held.ID); err == nil {
+		t.Error("an item of a paid order was deleted")
+	}
+}
+
+func TestCloseShift(t *testing.T) {
+	service, _ := newService(t)
+	if _, err := service.OpenShift(mesero, 10000); err != nil {
+		t.Fatalf("OpenShift: %v", err)
+	}
+	if _, err := service.OpenShift(mesero, 0); err == nil {
+		t.Error("a second shift was opened")
+	}
+
+	addItem(t, service, 1, 2, 4)
+	order := orderOf(t, service, 4)
+	if _, err := service.RegisterPayment(mesero, order.ID, "Debito", 3000, 0); err == nil {
+		t.Error("a payment above the outstanding amount was accepted")
+	}
+	if _, err := service.RegisterPayment(mesero, order.ID, "Debito", 1000, 0); err != nil {
+		t.Fatalf("RegisterPayment: %v", err)
+	}
+	if _, err := service.CloseShift(mesero, false); err == nil {
+		t.Error("the shift was closed with a pending order")
+	}
+	if _, err := service.RegisterPayment(mesero, order.ID, "Efectivo", 1400, 200); err != nil {
+		t.Fatalf("RegisterPayment: %v", err)
+	}
+	order, err := service.GetOrderByID(order.ID)
+	if err != nil {
+		t.Fatalf("GetOrderByID: %v", err)
+	}
+	if order.Estado != "Pagada" {
+		t.Errorf("order estado = %s, want Pagada", order.Estado)
+	}
+
+	report, err := service.CloseShift(mesero, false)
+	if err != nil {
+		t.Fatalf("CloseShift: %v", err)
+	}
+	if report.TotalSales != 2400 || report.TotalTips != 200 || report.PaymentsCount != 2 {
+		t.Errorf("report sales = %d, tips = %d, payments = %d; want 2400, 200, 2", report.TotalSales, report.TotalTips, report.PaymentsCount)
+	}
+	if report.ExpectedCash != 11600 {
+		t.Errorf("expected cash = %d, want 11600", report.ExpectedCash)
+	}
+	if stored, err := service.GetZReport(report.ShiftID); err != nil || stored.ID != report.ID {
+		t.Errorf("GetZReport(%d) = %v, %v", report.ShiftID, stored, err)
+	}
+	if _, err := service.GetCurrentShift(); err == nil {
+		t.Error("the shift is still open after closing it")
+	}
+}
+
+func TestProductTranslations(t *testing.T) {
+	service, store := newService(t)
+	if _, err := service.SetProductTranslation(mesero, "Lomo", "en", "Steak", "Steak and fries"); err != nil {
+		t.Fatalf("SetProductTranslation: %v", err)
+	}
+	if _, err := service.SetProductTranslation(mesero, "Lomo", "en", "Sirloin", "Sirloin and fries"); err != nil {
+		t.Fatalf("SetProductTranslation: %v", err)
+	}
+	translations, err := service.GetProductTranslations("Lomo")
+	if err != nil {
+		t.Fatalf("GetProductTranslations: %v", err)
+	}
+	if len(translations) != 1 || translations[0].Name != "Sirloin" {
+		t.Errorf("translations = %+v, want only Sirloin", translations)
+	}
+
+	if _, err := service.DeleteProductTranslation(mesero, "Lomo", "en"); err != nil {
+		t.Fatalf("DeleteProductTranslation: %v", err)
+	}
+	if _, err := service.DeleteProductTranslation(mesero, "Lomo", "en"); err == nil {
+		t.Error("a deleted translation was deleted again")
+	}
+	first, err := service.SetCategoryTranslation(mesero, "fondos", "en", "Mains")
+	if err != nil {
+		t.Fatalf("SetCategoryTranslation: %v", err)
+	}
+	second, err := service.SetCategoryTranslation(mesero, "fondos", "en", "Main courses")
+	if err != nil {
+		t.Fatalf("SetCategoryTranslation: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("replaced category translation has ID %d, want %d", second.ID, first.ID)
+	}
+
+	var actions []string
+	for _, entry := range store.(*repository.MemoryStore).AuditLogs() {
+		if entry.Entity == "CategoryTranslation" {
+			actions = append(actions, entry.Action)
+			if entry.Action == "update" && len(entry.Before) == 0 {
+				t.Error("the category translation update has no previous row")
+			}
+		}
+	}
+	if len(actions) != 2 || actions[0] != "create" || actions[1] != "update" {
+		t.Errorf("category translation audit actions = %v, want [create update]", actions)
+	}
+}
+
+func TestCategoryTranslationsPerBranch(t *testing.T) {
+	store := repository.NewMemoryStore()
+	catalog := models.AuditInfo{UserID: 1, Role: "admin", BranchID: 0, Pattern: "TEST"}
+	centro := models.AuditInfo{UserID: 4, Role: "mesero", BranchID: 2, Pattern: "TEST"}
+	for _, set := range []struct {
+		audit models.AuditInfo
+		name  string
+	}{{catalog, "Mains"}, {mesero, "Main courses"}, {centro, "Entrées"}} {
+		if _, err := controllers.NewService(store.Branch(set.audit.BranchID)).SetCategoryTranslation(set.audit, "fondos", "en", set.name); err != nil {
+			t.Fatalf("SetCategoryTranslation(%s): %v", set.name, err)
+		}
+	}
+
+	for branchID, want := range map[uint]string{1: "Main courses", 2: "Entrées", 3: "Mains"} {
+		translation, err := store.Branch(branchID).Translations().FindCategory("fondos", "en")
+		if err != nil {
+			t.Fatalf("FindCategory in branch %d: %v", branchID, err)
+		}
+		if translation.Name != want {
+			t.Errorf("branch %d translates fondos as %q, want %q", branchID, translation.Name, want)
+		}
+	}
+}
+
+func TestSharedProductInOtherCurrency(t *testing.T) {
+	store := repository.NewMemoryStore()
+	admin := models.AuditInfo{UserID: 1, Role: "admin", BranchID: 0, Pattern: "TEST"}
+	catalog := controllers.NewService(store.Branch(0))
+	branch, err := catalog.CreateBranch(admin, "Miami", "USD")
+	if err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	product, err := catalog.CreateProduct(admin, "Pisco sour", "Pisco sour", 5000, "bebidas")
+	if err != nil {
+		t.Fatalf("CreateProduct: %v", err)
+	}
+
+	miami := models.AuditInfo{UserID: 4, Role: "mesero", BranchID: branch.ID, Pattern: "TEST"}
+	service := controllers.NewService(store.Branch(branch.ID))
+	if _, err := service.AddOrderItem(miami, miami.UserID, product.ID, 1, 1, 0, false); err == nil {
+		t.Error("a shared product priced in CLP was sold in a USD branch")
+	}
+
+	price := money.Amount(650)
+	if _, err := service.SetProductOverride(miami, product.Name, &price, false); err != nil {
+		t.Fatalf("SetProductOverride: %v", err)
+	}
+	item, err := service.AddOrderItem(miami, miami.UserID, product.ID, 1, 1, 0, false)
+	if err != nil {
+		t.Fatalf("AddOrderItem: %v", err)
+	}
+	if item.UnitPrice != price {
+		t.Errorf("unit price = %d, want the branch price %d", item.UnitPrice, price)
+	}
+
+	// La casa matriz cobra en la moneda del catálogo
+	item, err = controllers.NewService(store.Branch(1)).AddOrderItem(mesero, mesero.UserID, product.ID, 1, 1, 0, false)
+	if err != nil {
+		t.Fatalf("AddOrderItem in branch 1: %v", err)
+	}
+	if item.UnitPrice != 5000 {
+		t.Errorf("unit price in branch 1 = %d, want 5000", item.UnitPrice)
+	}
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// findOpenShift busca el turno de caja abierto.
+func findOpenShift(tx repository.Store) (*models.Shift, error) {
+	shift, err := tx.Shifts().FindOpen()
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errors.New("there is no open shift")
+	}
+	return shift, err
+}
+
+// OpenShift abre un turno de caja con el efectivo inicial indicado.
+func (s *Service) OpenShift(audit models.AuditInfo, startingCash money.Amount) (*models.Shift, error) {
+	if startingCash < 0 {
+		return nil, errors.New("starting cash cannot be negative")
+	}
+
+	shift := models.Shift{
+		OpenedBy:     audit.UserID,
+		OpenedAt:     time.Now(),
+		StartingCash: startingCash,
+		Estado:       models.ShiftAbierto,
+	}
+	err := s.store.Transaction(func(tx repository.Store) error {
+		shift.Currency = branchCurrency(tx, audit.BranchID).Code
+		// Dos aperturas simultáneas no abren dos turnos: la segunda no guarda nada.
+		created, err := tx.Shifts().CreateOpen(&shift)
+		if err != nil {
+			return fmt.Errorf("failed to open shift: %w", err)
+		}
+		if !created {
+			return errors.New("a shift is already open")
+		}
+		return storeAudit(tx, audit, "Shift", shift.ID, "create", nil, shift)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetCurrentShift devuelve el turno abierto de la sucursal con sus pagos.
+func (s *Service) GetCurrentShift() (*models.Shift, error) {
+	shift, err := findOpenShift(s.store)
+	if err != nil {
+		return nil, err
+	}
+	if shift.Payments, err = s.store.Payments().FindByShift(shift.ID); err != nil {
+		return nil, err
+	}
+	return shift, nil
+}
+
+// RegisterPayment registra un pago de la comanda en el turno abierto. Cuando los pagos cubren
+// el total, la comanda queda con estado "Pagada". En efectivo el saldo se redondea al múltiplo que
+// se puede pagar (en CLP, a la decena) y la diferencia queda en Rounding.
+func (s *Service) RegisterPayment(audit models.AuditInfo, orderID uint, tender string, amount money.Amount, tip money.Amount) (*models.Payment, error) {
+	if !models.Tenders[tender] {
+		return nil, fmt.Errorf("invalid tender: %s", tender)
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if tip < 0 {
+		return nil, errors.New("tip cannot be negative")
+	}
+
+	var payment models.Payment
+	err := s.store.Transaction(func(tx repository.Store) error {
+		shift, err := findOpenShift(tx)
+		if err != nil {
+			return err
+		}
+
+		// Se bloquea la comanda para que dos pagos parciales simultáneos no superen el saldo
+		order, err := tx.Orders().FindByIDForUpdate(orderID)
+		if err != nil {
+			return fmt.Errorf("Order not found: %v", err)
+		}
+		if order.Estado != "Pendiente" {
+			return errors.New("only pending orders can be paid")
+		}
+
+		payments, err := tx.Payments().FindByOrder(order.ID)
+		if err != nil {
+			return err
+		}
+		var paid money.Amount
+		for _, p := range payments {
+			paid += p.Amount + p.Rounding
+		}
+		currency := currencyByCode(order.Currency)
+		outstanding := order.TotalAmount - paid
+		due := outstanding
+		if tender == "Efectivo" {
+			due = currency.RoundCash(outstanding)
+		}
+		if amount > due {
+			return fmt.Errorf("payment exceeds the outstanding amount of %s", currency.Format(due))
+		}
+
+		payment = models.Payment{
+			ShiftID: shift.ID,
+			OrderID: order.ID,
+			Tender:  tender,
+			Amount:  amount,
+			Tip:     tip,
+			UserID:  audit.UserID,
+		}
+		if amount == due {
+			payment.Rounding = outstanding - due
+		}
+		if err := tx.Payments().Create(&payment); err != nil {
+			return fmt.Errorf("failed to register payment: %w", err)
+		}
+		if err := storeAudit(tx, audit, "Payment", payment.ID, "create", nil, payment); err != nil {
+			return err
+		}
+
+		if amount == due {
+			before := orderSnapshot(order)
+			order.Estado = "Pagada"
+			if err := tx.Orders().Save(order); err != nil {
+				return fmt.Errorf("failed to update order status: %w", err)
+			}
+			return storeAudit(tx, audit, "Order", order.ID, "update", before, orderSnapshot(order))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// CloseShift cierra el turno abierto y genera su informe Z. Si quedan comandas pendientes
+// el cierre se rechaza, salvo que se fuerce. Los descuentos del informe son las cortesías: no hay
+// otra forma de rebajar una comanda.
+func (s *Service) CloseShift(audit models.AuditInfo, force bool) (*models.ZReport, error) {
+	var report models.ZReport
+	err := s.store.Transaction(func(tx repository.Store) error {
+		shift, err := findOpenShift(tx)
+		if err != nil {
+			return err
+		}
+
+		// Comandas que siguen pendientes
+		pending, err := tx.Orders().FindActive()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 && !force {
+			return fmt.Errorf("there are %d pending orders; close them or force the shift close", len(pending))
+		}
+
+		now := time.Now()
+		report = models.ZReport{
+			ShiftID:       shift.ID,
+			GeneratedAt:   now,
+			GeneratedBy:   audit.UserID,
+			StartingCash:  shift.StartingCash,
+			ExpectedCash:  shift.StartingCash,
+			PendingOrders: len(pending),
+			Forced:        len(pending) > 0,
+			Currency:      shift.Currency,
+		}
+		for _, order := range pending {
+			report.PendingAmount += order.TotalAmount
+		}
+
+		// Totales por medio de pago
+		payments, err := tx.Payments().FindByShift(shift.ID)
+		if err != nil {
+			return err
+		}
+		totals := map[string]money.Amount{}
+		for _, p := range payments {
+			totals[p.Tender] += p.Amount
+			report.TotalSales += p.Amount
+			report.TotalTips += p.Tip
+			report.TotalRounding += p.Rounding
+			report.PaymentsCount++
+			if p.Tender == "Efectivo" {
+				report.ExpectedCash += p.Amount + p.Tip
+			}
+		}
+		report.TotalsByTender, _ = json.Marshal(totals)
+
+		// Anulaciones y cortesías hechas durante el turno
+		voided, err := tx.OrderItems().FindVoided(shift.OpenedAt, now)
+		if err != nil {
+			return err
+		}
+		for _, item := range voided {
+			if item.Estado == models.OrderItemAnulado {
+				report.VoidsCount++
+				report.VoidsAmount += item.TotalPrice
+			} else {
+				report.CompsCount++
+				report.CompsAmount += item.TotalPrice
+			}
+		}
+
+		if err := tx.Shifts().CreateZReport(&report); err != nil {
+			return fmt.Errorf("failed to generate z report: %w", err)
+		}
+
+		before := snapshot(shift)
+		shift.Estado = models.ShiftCerrado
+		shift.ClosedAt = &now
+		shift.ClosedBy = &audit.UserID
+		if err := tx.Shifts().Save(shift); err != nil {
+			return fmt.Errorf("failed to close shift: %w", err)
+		}
+		return storeAudit(tx, audit, "Shift", shift.ID, "update", before, shift)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// GetZReport obtiene el informe Z de un turno cerrado de la sucursal.
+func (s *Service) GetZReport(shiftID uint) (*models.ZReport, error) {
+	report, err := s.store.Shifts().FindZReport(shiftID)
+	if err != nil {
+		return nil, fmt.Errorf("z report not found: %v", err)
+	}
+	return report, nil
+}
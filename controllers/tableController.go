@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// Intentos de recalcular el total cuando otra transacción cambió la comanda al mismo tiempo.
+const recalculateAttempts = 3
+
+// recalculateOrderTotal vuelve a sumar los TotalPrice de los items activos de la orden y guarda el resultado.
+// Si otra transacción cambió la comanda entretanto, la vuelve a leer y recalcula, ya que el total
+// solo depende de los items.
+func recalculateOrderTotal(tx repository.Store, order *models.Order) error {
+	for attempt := 1; ; attempt++ {
+		items, err := tx.OrderItems().FindByOrder(order.ID)
+		if err != nil {
+			return fmt.Errorf("failed to calculate total amount: %w", err)
+		}
+
+		var totalAmount money.Amount
+		for _, item := range items {
+			if item.Estado == models.OrderItemActivo {
+				totalAmount += item.TotalPrice
+			}
+		}
+
+		order.TotalAmount = totalAmount
+		err = tx.Orders().Save(order)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, repository.ErrConflict) || attempt == recalculateAttempts {
+			return fmt.Errorf("failed to update total amount: %w", err)
+		}
+
+		fresh, err := tx.Orders().FindByID(order.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reload order: %w", err)
+		}
+		*order = *fresh
+	}
+}
+
+// checkVersion verifica que la comanda siga en la versión que vio el cliente. 0 no verifica.
+func checkVersion(order *models.Order, version int) error {
+	if version != 0 && order.Version != version {
+		return repository.ErrConflict
+	}
+	return nil
+}
+
+// findOrCreateOpenOrder devuelve la orden pendiente de la mesa, creándola si no existe.
+// created indica si la orden se acaba de crear.
+// Una mesa nunca tiene dos órdenes pendientes, aunque dos meseros le agreguen el primer item a la vez.
+func findOrCreateOpenOrder(tx repository.Store, tableNumber int, userID uint, currency string) (order *models.Order, created bool, err error) {
+	order, created, err = tx.Orders().FindOrCreateOpen(&models.Order{
+		UserID:      userID,
+		TableNumber: tableNumber,
+		OrderDate:   time.Now(),
+		Estado:      "Pendiente",
+		Currency:    currency,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find or create open order: %w", err)
+	}
+	return order, created, nil
+}
+
+// moveOrderItems pasa los items a otra orden y mesa.
+func moveOrderItems(tx repository.Store, items []models.OrderItem, orderID uint, tableNumber int) error {
+	for i := range items {
+		items[i].OrderID = &orderID
+		items[i].TableNumber = tableNumber
+		if err := tx.OrderItems().Save(&items[i]); err != nil {
+			return fmt.Errorf("failed to move order items: %w", err)
+		}
+	}
+	return nil
+}
+
+// TransferOrder mueve la orden pendiente de una mesa a otra mesa libre.
+func (s *Service) TransferOrder(audit models.AuditInfo, fromTable int, toTable int) (*models.Order, error) {
+	if fromTable == toTable {
+		return nil, errors.New("source and destination tables must be different")
+	}
+
+	var orderID uint
+	err := s.store.Transaction(func(tx repository.Store) error {
+		order, err := tx.Orders().FindOpenByTable(fromTable)
+		if err != nil {
+			return fmt.Errorf("no open order for table %d: %w", fromTable, err)
+		}
+		orderID = order.ID
+
+		// La mesa de destino no puede tener una orden pendiente
+		if _, err := tx.Orders().FindOpenByTable(toTable); err == nil {
+			return fmt.Errorf("table %d already has an open order", toTable)
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+
+		before := orderSnapshot(order)
+		order.TableNumber = toTable
+		if err := tx.Orders().Save(order); err != nil {
+			return fmt.Errorf("failed to update order table: %w", err)
+		}
+
+		// Los items guardan también el número de mesa
+		items, err := tx.OrderItems().FindByOrder(order.ID)
+		if err != nil {
+			return err
+		}
+		if err := moveOrderItems(tx, items, order.ID, toTable); err != nil {
+			return err
+		}
+
+		return storeAudit(tx, audit, "Order", order.ID, "update", before, orderSnapshot(order))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOrderByID(orderID)
+}
+
+// MergeOrders junta la orden pendiente de sourceTable dentro de la orden pendiente de targetTable.
+// La orden de origen queda con estado "Fusionada" y sin items.
+func (s *Service) MergeOrders(audit models.AuditInfo, sourceTable int, targetTable int) (*models.Order, error) {
+	if sourceTable == targetTable {
+		return nil, errors.New("source and target tables must be different")
+	}
+
+	var targetID uint
+	err := s.store.Transaction(func(tx repository.Store) error {
+		source, err := tx.Orders().FindOpenByTable(sourceTable)
+		if err != nil {
+			return fmt.Errorf("no open order for table %d: %w", sourceTable, err)
+		}
+		target, err := tx.Orders().FindOpenByTable(targetTable)
+		if err != nil {
+			return fmt.Errorf("no open order for table %d: %w", targetTable, err)
+		}
+		targetID = target.ID
+
+		sourceBefore, targetBefore := orderSnapshot(source), orderSnapshot(target)
+
+		// Reasignar los items de la orden de origen a la orden de destino
+		items, err := tx.OrderItems().FindByOrder(source.ID)
+		if err != nil {
+			return err
+		}
+		if err := moveOrderItems(tx, items, target.ID, targetTable); err != nil {
+			return err
+		}
+
+		source.Estado = "Fusionada"
+		if err := tx.Orders().Save(source); err != nil {
+			return fmt.Errorf("failed to update order: %w", err)
+		}
+		if err := recalculateOrderTotal(tx, source); err != nil {
+			return err
+		}
+		if err := recalculateOrderTotal(tx, target); err != nil {
+			return err
+		}
+
+		if err := storeAudit(tx, audit, "Order", source.ID, "update", sourceBefore, orderSnapshot(source)); err != nil {
+			return err
+		}
+		return storeAudit(tx, audit, "Order", target.ID, "update", targetBefore, orderSnapshot(target))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOrderByID(targetID)
+}
+
+// SplitOrder separa los items indicados de una orden y los pasa a la orden pendiente de toTable,
+// creándola si la mesa no tiene una.
+func (s *Service) SplitOrder(audit models.AuditInfo, orderID uint, itemIDs []uint, toTable int) (*models.Order, error) {
+	if len(itemIDs) == 0 {
+		return nil, errors.New("no items selected to split")
+	}
+
+	var targetID uint
+	err := s.store.Transaction(func(tx repository.Store) error {
+		source, err := tx.Orders().FindByID(orderID)
+		if err != nil {
+			return fmt.Errorf("Order not found: %v", err)
+		}
+		if source.Estado != "Pendiente" {
+			return errors.New("only pending orders can be split")
+		}
+		if source.TableNumber == toTable {
+			return errors.New("destination table must be different from the order's table")
+		}
+
+		// Todos los items deben pertenecer a la orden de origen
+		var items []models.OrderItem
+		for _, itemID := range itemIDs {
+			item, err := tx.OrderItems().FindByID(itemID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return err
+			}
+			if err != nil || item.OrderID == nil || *item.OrderID != source.ID {
+				return errors.New("some items do not belong to the order")
+			}
+			items = append(items, *item)
+		}
+
+		sourceBefore := orderSnapshot(source)
+		target, _, err := findOrCreateOpenOrder(tx, toTable, source.UserID, source.Currency)
+		if err != nil {
+			return err
+		}
+		targetID = target.ID
+		targetBefore := orderSnapshot(target)
+
+		if err := moveOrderItems(tx, items, target.ID, toTable); err != nil {
+			return err
+		}
+
+		if err := recalculateOrderTotal(tx, source); err != nil {
+			return err
+		}
+		if err := recalculateOrderTotal(tx, target); err != nil {
+			return err
+		}
+
+		if err := storeAudit(tx, audit, "Order", source.ID, "update", sourceBefore, orderSnapshot(source)); err != nil {
+			return err
+		}
+		return storeAudit(tx, audit, "Order", target.ID, "update", targetBefore, orderSnapshot(target))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetOrderByID(targetID)
+}
+
+// GetOrderByID obtiene una orden con sus items y productos.
+func (s *Service) GetOrderByID(orderID uint) (*models.Order, error) {
+	order, err := s.store.Orders().FindByID(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("Order not found: %v", err)
+	}
+	return order, nil
+}
+
+// GetOrderByTable obtiene la orden pendiente de una mesa con sus items y productos.
+func (s *Service) GetOrderByTable(tableNumber int) (*models.Order, error) {
+	order, err := s.store.Orders().FindOpenByTable(tableNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("no open order for table %d", tableNumber)
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetActiveOrders lista las órdenes pendientes de todas las mesas.
+func (s *Service) GetActiveOrders() ([]models.Order, error) {
+	return s.store.Orders().FindActive()
+}
+
+// GetOrdersByUser lista las órdenes asignadas a un usuario, de la más reciente a la más antigua.
+func (s *Service) GetOrdersByUser(userID uint) ([]models.Order, error) {
+	return s.store.Orders().FindByUser(userID)
+}
+
+// Campos por los que se pueden ordenar las comandas.
+var orderSortColumns = map[string]sortColumn{
+	"id":           {"id", sortInt},
+	"order_date":   {"order_date", sortTime},
+	"total_amount": {"total_amount", sortInt},
+	"table_number": {"table_number", sortInt},
+}
+
+// ListOrders lista comandas paginadas de la sucursal con sus items, aplicando los filtros de params.
+func ListOrders(branchID uint, params models.ListParams) (*models.Page[models.Order], error) {
+	tx := branchDB(branchID)
+	query := tx.Model(&models.Order{})
+	if params.Status != "" {
+		query = query.Where("estado = ?", params.Status)
+	}
+	if params.TableNumber != nil {
+		query = query.Where("table_number = ?", *params.TableNumber)
+	}
+	if params.UserID != nil {
+		query = query.Where("user_id = ?", *params.UserID)
+	}
+	if params.From != nil {
+		query = query.Where("order_date >= ?", *params.From)
+	}
+	if params.To != nil {
+		query = query.Where("order_date < ?", *params.To)
+	}
+	if params.ProductID != nil {
+		query = query.Where("id IN (?)", tx.Model(&models.OrderItem{}).Select("order_id").Where("product_id = ?", *params.ProductID))
+	}
+
+	return paginate(query, params, orderSortColumns, "order_date",
+		func(o models.Order, sortBy string) (interface{}, uint) {
+			switch sortBy {
+			case "order_date":
+				return o.OrderDate, o.ID
+			case "total_amount":
+				return o.TotalAmount, o.ID
+			case "table_number":
+				return o.TableNumber, o.ID
+			default:
+				return o.ID, o.ID
+			}
+		}, "Items.Product")
+}
+
+// allergyWarnings devuelve un aviso por cada alérgeno del producto declarado por los comensales.
+func allergyWarnings(guestAllergies []string, productAllergens []string) []string {
+	var warnings []string
+	for _, allergy := range guestAllergies {
+		for _, allergen := range productAllergens {
+			if allergy == allergen {
+				warnings = append(warnings, fmt.Sprintf("contains %s, declared as an allergy at this table", models.Allergens[allergen]))
+			}
+		}
+	}
+	return warnings
+}
+
+// SetOrderAllergies registra las alergias declaradas por los comensales de la orden pendiente de una
+// mesa y devuelve avisos por los items ya pedidos que las contienen.
+// Si version no es 0 debe coincidir con la versión actual de la comanda.
+func (s *Service) SetOrderAllergies(audit models.AuditInfo, tableNumber int, allergies []string, version int) (*models.Order, error) {
+	if err := validateTags(allergies, models.Allergens, "allergen"); err != nil {
+		return nil, err
+	}
+
+	var order *models.Order
+	err := s.store.Transaction(func(tx repository.Store) error {
+		var err error
+		order, err = tx.Orders().FindOpenByTable(tableNumber)
+		if err != nil {
+			return fmt.Errorf("no open order for table %d: %w", tableNumber, err)
+		}
+		if err := checkVersion(order, version); err != nil {
+			return err
+		}
+		before := orderSnapshot(order)
+
+		order.GuestAllergies = allergies
+		if err := tx.Orders().Save(order); err != nil {
+			return fmt.Errorf("failed to update order allergies: %w", err)
+		}
+
+		return storeAudit(tx, audit, "Order", order.ID, "update", before, orderSnapshot(order))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range order.Items {
+		order.Items[i].Warnings = allergyWarnings(allergies, order.Items[i].Allergens)
+	}
+	return order, nil
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// localizeProducts reemplaza nombre, descripción y categoría por su traducción al idioma pedido.
+// Los textos sin traducción quedan en español, y con el idioma por defecto no se consulta nada.
+func localizeProducts(store repository.Store, products []models.Product, locale string) error {
+	if locale == "" || locale == models.DefaultLocale || len(products) == 0 {
+		return nil
+	}
+	if !models.SupportedLocales[locale] {
+		return fmt.Errorf("unsupported locale: %s", locale)
+	}
+
+	ids := make([]uint, len(products))
+	categories := make([]string, 0, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+		categories = append(categories, p.Category)
+	}
+
+	translations, err := store.Translations().ForProducts(locale, ids)
+	if err != nil {
+		return err
+	}
+	byProduct := map[uint]models.ProductTranslation{}
+	for _, t := range translations {
+		byProduct[t.ProductID] = t
+	}
+
+	categoryTranslations, err := store.Translations().ForCategories(locale, categories)
+	if err != nil {
+		return err
+	}
+	byCategory := map[string]string{}
+	for _, t := range categoryTranslations {
+		byCategory[t.Category] = t.Name
+	}
+
+	for i := range products {
+		if t, ok := byProduct[products[i].ID]; ok {
+			products[i].Name = t.Name
+			products[i].Description = t.Description
+		}
+		if name, ok := byCategory[products[i].Category]; ok {
+			products[i].Category = name
+		}
+	}
+	return nil
+}
+
+// translationChange recibe la búsqueda de la traducción que se va a guardar y devuelve lo que
+// queda en la auditoría: la traducción anterior y "update", o nada y "create" si no existía.
+func translationChange[T any](existing *T, err error) (json.RawMessage, string, error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, "create", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return snapshot(existing), "update", nil
+}
+
+// SetProductTranslation crea o reemplaza la traducción de un producto a un idioma.
+func (s *Service) SetProductTranslation(audit models.AuditInfo, productName string, locale string, name string, description string) (*models.ProductTranslation, error) {
+	if locale == models.DefaultLocale || !models.SupportedLocales[locale] {
+		return nil, fmt.Errorf("unsupported translation locale: %s", locale)
+	}
+	if name == "" || description == "" {
+		return nil, errors.New("translated name and description are required")
+	}
+
+	var translation models.ProductTranslation
+	err := s.store.Transaction(func(tx repository.Store) error {
+		product, err := tx.Products().FindByName(productName)
+		if err != nil {
+			return fmt.Errorf("product not found: %w", err)
+		}
+		if err := ownProduct(product, audit.BranchID); err != nil {
+			return err
+		}
+
+		before, action, err := translationChange(tx.Translations().FindProduct(product.ID, locale))
+		if err != nil {
+			return err
+		}
+
+		translation = models.ProductTranslation{ProductID: product.ID, Locale: locale, Name: name, Description: description}
+		if err := tx.Translations().SaveProduct(&translation); err != nil {
+			return fmt.Errorf("failed to save translation: %w", err)
+		}
+
+		return storeAudit(tx, audit, "ProductTranslation", product.ID, action, before, translation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// DeleteProductTranslation borra la traducción de un producto; se vuelve a mostrar en español.
+func (s *Service) DeleteProductTranslation(audit models.AuditInfo, productName string, locale string) (*models.ProductTranslation, error) {
+	var translation *models.ProductTranslation
+	err := s.store.Transaction(func(tx repository.Store) error {
+		product, err := tx.Products().FindByName(productName)
+		if err != nil {
+			return fmt.Errorf("translation not found: %w", err)
+		}
+		if err := ownProduct(product, audit.BranchID); err != nil {
+			return err
+		}
+
+		translation, err = tx.Translations().FindProduct(product.ID, locale)
+		if err != nil {
+			return fmt.Errorf("translation not found: %w", err)
+		}
+		if err := tx.Translations().DeleteProduct(translation); err != nil {
+			return err
+		}
+
+		return storeAudit(tx, audit, "ProductTranslation", translation.ProductID, "delete", snapshot(translation), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return translation, nil
+}
+
+// GetProductTranslations lista todas las traducciones de un producto del menú de la sucursal.
+func (s *Service) GetProductTranslations(productName string) ([]models.ProductTranslation, error) {
+	product, err := s.store.Products().FindByName(productName)
+	if errors.Is(err, repository.ErrNotFound) {
+		return []models.ProductTranslation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Translations().FindByProduct(product.ID)
+}
+
+// SetCategoryTranslation crea o reemplaza la traducción del nombre de una categoría en la sucursal.
+// Las otras sucursales siguen viendo la suya, o la del catálogo común.
+func (s *Service) SetCategoryTranslation(audit models.AuditInfo, category string, locale string, name string) (*models.CategoryTranslation, error) {
+	if locale == models.DefaultLocale || !models.SupportedLocales[locale] {
+		return nil, fmt.Errorf("unsupported translation locale: %s", locale)
+	}
+	if category == "" || name == "" {
+		return nil, errors.New("category and translated name are required")
+	}
+
+	translation := models.CategoryTranslation{Category: category, Locale: locale, Name: name}
+	err := s.store.Transaction(func(tx repository.Store) error {
+		existing, err := tx.Translations().FindCategory(category, locale)
+		if err == nil && existing.BranchID != audit.BranchID {
+			// La del catálogo común no se reemplaza: la sucursal crea la suya
+			err = repository.ErrNotFound
+		}
+		before, action, err := translationChange(existing, err)
+		if err != nil {
+			return err
+		}
+		if err := tx.Translations().SaveCategory(&translation); err != nil {
+			return fmt.Errorf("failed to save translation: %w", err)
+		}
+		return storeAudit(tx, audit, "CategoryTranslation", translation.ID, action, before, translation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
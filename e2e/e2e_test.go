@@ -0,0 +1,190 @@
+package e2e_test
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/broker"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/controllers"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/e2e"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/migrations"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// run envía los casos por un broker en proceso y marca como fallido el subtest de cada caso que
+// no reciba la respuesta esperada.
+func run(t *testing.T, cases []e2e.Case) {
+	t.Helper()
+	fake := broker.NewFake()
+	defer fake.Close()
+	if err := e2e.Serve(fake, e2e.Queue, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, result := range e2e.Run(fake, e2e.Queue, cases, 5*time.Second) {
+		result := result
+		t.Run(fmt.Sprintf("%02d %s", i+1, result.Name), func(t *testing.T) {
+			if result.Passed {
+				return
+			}
+			if result.Reply != nil {
+				t.Errorf("%s: %s (%s: %s)", result.Pattern, result.Failure, result.Reply.Message, result.Reply.Data)
+			} else {
+				t.Errorf("%s: %s", result.Pattern, result.Failure)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	cases, err := e2e.DefaultCases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e2e.Configure(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	controllers.UseStore(repository.NewMemoryStore())
+	t.Cleanup(func() { controllers.UseStore(nil) })
+
+	run(t, cases)
+}
+
+// Los patrones que usan SQL directamente corren sobre la base de TEST_DB_URL, en un esquema nuevo
+// que se borra al terminar. Las extensiones quedan en public porque no se pueden instalar dos veces.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set; the SQL-only patterns need a Postgres database")
+	}
+	cases, err := e2e.PostgresCases()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	silent := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	admin, err := gorm.Open(postgres.Open(dsn), silent)
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	schema := fmt.Sprintf("e2e_%d", time.Now().UnixNano())
+	for _, stmt := range []string{
+		"CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public",
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public",
+		"CREATE SCHEMA " + schema,
+	} {
+		if err := admin.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	conn, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema+",public")), silent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tenant.Register(conn, tenant.CatalogTables...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.Up(conn, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e2e.Configure(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	previous := config.DB
+	config.DB = conn
+	controllers.UseStore(nil)
+	t.Cleanup(func() { config.DB = previous })
+
+	run(t, cases)
+}
+
+// withSearchPath agrega search_path a la cadena de conexión, sea una URL o pares clave=valor.
+func withSearchPath(dsn string, path string) string {
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + strings.ReplaceAll(path, ",", "%2C")
+	}
+	return dsn + " search_path=" + path
+}
+
+// Cada patrón del switch de internal.Handler debe tener al menos un caso, en memoria o en Postgres.
+func TestEveryPatternHasCases(t *testing.T) {
+	patterns, err := handlerPatterns("../internal/handler.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patterns) == 0 {
+		t.Fatal("no patterns found in internal.Handler")
+	}
+
+	covered := map[string]bool{}
+	for _, load := range []func() ([]e2e.Case, error){e2e.DefaultCases, e2e.PostgresCases} {
+		cases, err := load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, c := range cases {
+			covered[c.Pattern] = true
+		}
+	}
+	for _, pattern := range patterns {
+		if !covered[pattern] {
+			t.Errorf("pattern %s has no e2e case", pattern)
+		}
+	}
+}
+
+// handlerPatterns devuelve los patrones del switch sobre actionType en la función Handler.
+func handlerPatterns(path string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "Handler" {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			sw, ok := n.(*ast.SwitchStmt)
+			if !ok {
+				return true
+			}
+			if tag, ok := sw.Tag.(*ast.Ident); !ok || tag.Name != "actionType" {
+				return true
+			}
+			for _, stmt := range sw.Body.List {
+				for _, expr := range stmt.(*ast.CaseClause).List {
+					if lit, ok := expr.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						pattern, err := strconv.Unquote(lit.Value)
+						if err == nil {
+							patterns = append(patterns, pattern)
+						}
+					}
+				}
+			}
+			return false
+		})
+	}
+	return patterns, nil
+}
@@ -0,0 +1,296 @@
+// Package e2e ejecuta el handler RPC de punta a punta sobre el broker en proceso: envía sobres
+// {pattern, data, id, headers} como los de los clientes y compara las respuestas con lo esperado.
+package e2e
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/broker"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/internal"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Cola en la que escucha el microservicio.
+const Queue = "orders"
+
+// Case es una petición al microservicio y la respuesta que se espera.
+type Case struct {
+	Name    string          `json:"name"`
+	Pattern string          `json:"pattern"`
+	Data    json.RawMessage `json:"data"`
+	Headers models.Headers  `json:"headers"`
+	// Token son los claims de un JWT que se firma con JWT_SECRET y se envía en Authorization.
+	Token map[string]interface{} `json:"token,omitempty"`
+	// Tokens son claims de JWT que se firman igual y se agregan a Data con su clave, como
+	// manager_token.
+	Tokens map[string]map[string]interface{} `json:"tokens,omitempty"`
+	// Parallel envía la petición esa cantidad de veces a la vez; cada respuesta debe cumplir Expect.
+	Parallel int    `json:"parallel,omitempty"`
+	Expect   Expect `json:"expect"`
+}
+
+// Expect describe la respuesta esperada. Message y Data se comparan solo si vienen; en Data basta
+// con que los campos indicados coincidan, la respuesta puede traer otros.
+type Expect struct {
+	Success string          `json:"success"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Result es el resultado de un caso.
+type Result struct {
+	Name    string           `json:"name"`
+	Pattern string           `json:"pattern"`
+	Passed  bool             `json:"passed"`
+	Failure string           `json:"failure,omitempty"`
+	Reply   *models.Response `json:"reply,omitempty"`
+}
+
+//go:embed cases.json
+var defaultCases []byte
+
+// DefaultCases devuelve los casos incluidos, que cubren los patrones de comandas, items y
+// productos sobre un Store en memoria recién creado. Dependen del orden: cada caso parte del
+// estado que dejaron los anteriores.
+func DefaultCases() ([]Case, error) {
+	return LoadCases(defaultCases)
+}
+
+//go:embed postgres_cases.json
+var postgresCases []byte
+
+// PostgresCases devuelve los casos de los patrones que usan SQL directamente (reportes, búsqueda,
+// turnos, traducciones, imágenes y listados paginados). Parten de un esquema recién migrado y,
+// como DefaultCases, dependen del orden.
+func PostgresCases() ([]Case, error) {
+	return LoadCases(postgresCases)
+}
+
+// LoadCases lee una lista de casos en JSON.
+func LoadCases(data []byte) ([]Case, error) {
+	var cases []Case
+	if err := json.Unmarshal(data, &cases); err != nil {
+		return nil, fmt.Errorf("invalid cases file: %w", err)
+	}
+	return cases, nil
+}
+
+// Serve consume las peticiones de queue con workers consumidores, que las pasan a internal.Handler
+// hasta que se cierre el canal. Con más de un consumidor las peticiones paralelas se atienden a la
+// vez, como con varias réplicas del microservicio. Si el handler entra en pánico responde con un
+// error en vez de detener el proceso.
+func Serve(ch broker.Channel, queue string, workers int) error {
+	q, err := ch.QueueDeclare(queue, true, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+	msgs, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for d := range msgs {
+				handle(d, ch)
+			}
+		}()
+	}
+	return nil
+}
+
+func handle(d amqp.Delivery, ch broker.Channel) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Handler panicked: %v", r)
+			body, _ := json.Marshal(models.Response{
+				Success: "error",
+				Message: "Handler panicked",
+				Data:    []byte(fmt.Sprint(r)),
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			ch.PublishWithContext(ctx, "", d.ReplyTo, false, false, amqp.Publishing{
+				ContentType:   "application/json",
+				CorrelationId: d.CorrelationId,
+				Body:          body,
+			})
+			d.Nack(false, false)
+		}
+	}()
+	internal.Handler(d, ch)
+}
+
+// Run envía los casos en orden por el broker y compara cada respuesta con la esperada. Las copias
+// de un caso con Parallel se envían a la vez y el caso falla con la primera respuesta que no cumpla.
+func Run(fake *broker.Fake, queue string, cases []Case, timeout time.Duration) []Result {
+	results := make([]Result, 0, len(cases))
+	for i, c := range cases {
+		copies := c.Parallel
+		if copies < 1 {
+			copies = 1
+		}
+
+		replies := make([]Result, copies)
+		var wg sync.WaitGroup
+		for n := 0; n < copies; n++ {
+			wg.Add(1)
+			go func(n int) {
+				defer wg.Done()
+				replies[n] = call(fake, queue, c, fmt.Sprintf("e2e-%d-%d", i+1, n+1), timeout)
+			}(n)
+		}
+		wg.Wait()
+
+		result := replies[0]
+		for _, reply := range replies {
+			if !reply.Passed {
+				result = reply
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// call envía una petición del caso y compara la respuesta.
+func call(fake *broker.Fake, queue string, c Case, id string, timeout time.Duration) Result {
+	result := Result{Name: c.Name, Pattern: c.Pattern}
+
+	headers := c.Headers
+	if c.Token != nil {
+		token, err := Sign(c.Token, os.Getenv("JWT_SECRET"))
+		if err != nil {
+			result.Failure = err.Error()
+			return result
+		}
+		headers.Authorization = "Bearer " + token
+	}
+
+	data := c.Data
+	if len(c.Tokens) > 0 {
+		fields := map[string]interface{}{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &fields); err != nil {
+				result.Failure = fmt.Sprintf("tokens need object data: %v", err)
+				return result
+			}
+		}
+		for key, claims := range c.Tokens {
+			token, err := Sign(claims, os.Getenv("JWT_SECRET"))
+			if err != nil {
+				result.Failure = err.Error()
+				return result
+			}
+			fields[key] = token
+		}
+		signed, err := json.Marshal(fields)
+		if err != nil {
+			result.Failure = err.Error()
+			return result
+		}
+		data = signed
+	}
+
+	envelope, err := json.Marshal(map[string]interface{}{
+		"pattern": c.Pattern,
+		"data":    data,
+		"id":      id,
+		"headers": headers,
+	})
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	body, err := fake.Call(ctx, queue, envelope)
+	cancel()
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+
+	var reply models.Response
+	if err := json.Unmarshal(body, &reply); err != nil {
+		result.Failure = fmt.Sprintf("invalid reply: %v", err)
+		return result
+	}
+	result.Reply = &reply
+
+	if err := check(c.Expect, reply); err != nil {
+		result.Failure = err.Error()
+	} else {
+		result.Passed = true
+	}
+	return result
+}
+
+func check(expect Expect, reply models.Response) error {
+	if reply.Success != expect.Success {
+		return fmt.Errorf("success: expected %q, got %q (%s: %s)", expect.Success, reply.Success, reply.Message, reply.Data)
+	}
+	if expect.Message != "" && reply.Message != expect.Message {
+		return fmt.Errorf("message: expected %q, got %q", expect.Message, reply.Message)
+	}
+	if len(expect.Data) == 0 {
+		return nil
+	}
+
+	var expected, actual interface{}
+	if err := json.Unmarshal(expect.Data, &expected); err != nil {
+		return fmt.Errorf("invalid expected data: %w", err)
+	}
+	// Los errores vienen como texto plano, no como JSON
+	if err := json.Unmarshal(reply.Data, &actual); err != nil {
+		actual = string(reply.Data)
+	}
+	return match("data", expected, actual)
+}
+
+// match compara expected con actual: los objetos solo deben tener los campos de expected y las
+// listas deben tener el mismo largo y coincidir elemento a elemento.
+func match(path string, expected, actual interface{}) error {
+	switch want := expected.(type) {
+	case map[string]interface{}:
+		got, ok := actual.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %v", path, actual)
+		}
+		for key, value := range want {
+			if err := match(path+"."+key, value, got[key]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		got, ok := actual.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a list, got %v", path, actual)
+		}
+		if len(got) != len(want) {
+			return fmt.Errorf("%s: expected %d elements, got %d", path, len(want), len(got))
+		}
+		for i := range want {
+			if err := match(fmt.Sprintf("%s[%d]", path, i), want[i], got[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("%s: expected %v, got %v", path, expected, actual)
+		}
+		return nil
+	}
+}
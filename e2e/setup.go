@@ -0,0 +1,46 @@
+package e2e
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/media"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/printing"
+)
+
+// Configure deja la configuración global como la esperan los casos: moneda y boleta del entorno,
+// JWT_SECRET para firmar los tokens de los casos, e impresoras e imágenes en archivos bajo dir.
+//
+// En la casa matriz la cocina imprime en un archivo y la barra en uno que no se puede crear, para
+// probar los trabajos impresos y los fallidos sin impresoras. La sucursal 2 solo tiene impresora
+// en la cocina.
+func Configure(dir string) error {
+	config.SetupCurrency()
+	config.SetupReceipt()
+
+	if os.Getenv("JWT_SECRET") == "" {
+		os.Setenv("JWT_SECRET", "e2e-secret")
+	}
+
+	config.Printing = printing.Settings{
+		Printers: map[uint]map[string]string{
+			1: {
+				"cocina": "file://" + filepath.Join(dir, "cocina.bin"),
+				"barra":  "file://" + filepath.Join(dir, "sin-papel", "barra.bin"),
+			},
+			2: {"cocina": "file://" + filepath.Join(dir, "centro-cocina.bin")},
+		},
+		Categories:     map[string]string{"bebidas": "barra"},
+		DefaultStation: "cocina",
+		Width:          "80mm",
+		MaxAttempts:    1,
+	}
+
+	storage, err := media.NewLocalStorage(filepath.Join(dir, "media"), "/media")
+	if err != nil {
+		return err
+	}
+	config.Storage = storage
+	return nil
+}
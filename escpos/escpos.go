@@ -0,0 +1,104 @@
+// Package escpos arma la secuencia de bytes ESC/POS que entienden las impresoras térmicas de
+// recibos y de cocina. Solo cubre los comandos que usan los tickets: alineación, negrita, tamaño
+// doble, avance de papel y corte.
+package escpos
+
+import "bytes"
+
+// Alineaciones del texto.
+const (
+	Left   byte = 0
+	Center byte = 1
+	Right  byte = 2
+)
+
+// Writer acumula los comandos de un ticket.
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// New crea un ticket que reinicia la impresora y elige la página de códigos Windows-1252, para
+// imprimir acentos y eñes.
+func New() *Writer {
+	w := &Writer{}
+	w.buf.Write([]byte{0x1b, '@'})     // ESC @: reinicia la impresora
+	w.buf.Write([]byte{0x1b, 't', 16}) // ESC t 16: página de códigos WPC1252
+	return w
+}
+
+// Align cambia la alineación de las líneas siguientes.
+func (w *Writer) Align(align byte) *Writer {
+	w.buf.Write([]byte{0x1b, 'a', align})
+	return w
+}
+
+// Bold activa o desactiva la negrita.
+func (w *Writer) Bold(on bool) *Writer {
+	w.buf.Write([]byte{0x1b, 'E', flag(on)})
+	return w
+}
+
+// Double activa o desactiva el doble de alto y ancho. Con el tamaño doble caben la mitad de
+// caracteres por línea.
+func (w *Writer) Double(on bool) *Writer {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	w.buf.Write([]byte{0x1d, '!', size})
+	return w
+}
+
+// Line escribe una línea de texto.
+func (w *Writer) Line(text string) *Writer {
+	w.buf.Write(EncodeCP1252(text))
+	w.buf.WriteByte('\n')
+	return w
+}
+
+// Feed avanza el papel n líneas.
+func (w *Writer) Feed(lines int) *Writer {
+	w.buf.Write([]byte{0x1b, 'd', byte(lines)})
+	return w
+}
+
+// Cut avanza el papel hasta la cuchilla y hace un corte parcial.
+func (w *Writer) Cut() *Writer {
+	w.buf.Write([]byte{0x1d, 'V', 66, 0})
+	return w
+}
+
+// Bytes devuelve la secuencia armada.
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+func flag(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
+
+// Caracteres de Windows-1252 fuera de Latin-1.
+var cp1252 = map[rune]byte{
+	'€': 0x80, '‚': 0x82, '„': 0x84, '…': 0x85, '‘': 0x91, '’': 0x92,
+	'“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// EncodeCP1252 convierte el texto a Windows-1252, la codificación de la página de códigos que
+// elige New y de las fuentes estándar de PDF. Lo que no se puede representar queda como "?".
+func EncodeCP1252(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r >= 0x20 && r < 0x7f, r == '\n', r >= 0xa0 && r <= 0xff:
+			out = append(out, byte(r))
+		case cp1252[r] != 0:
+			out = append(out, cp1252[r])
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
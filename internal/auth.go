@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+)
+
+// authenticate valida un JWT HS256 firmado con JWT_SECRET y devuelve el usuario, su rol y la
+// sucursal de la petición. Acepta el token con o sin el prefijo "Bearer ". Sin token el usuario es
+// 0 (anónimo) y solo puede usar la sucursal de DEFAULT_BRANCH_ID (1 por defecto).
+//
+// Las sucursales permitidas salen de los claims "branch_id" y "branches" del token; la petición
+// puede elegir una de ellas en headers.Branch, y si no elige se usa la de "branch_id". Un token sin
+// esos claims queda, como las peticiones anónimas, en la sucursal por defecto. El catálogo común
+// (sucursal 0) nunca se usa sin un claim que lo permita.
+func authenticate(authorization string, requestedBranch *uint) (userID uint, role string, branchID uint, err error) {
+	claims, err := tokenClaims(authorization)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	if claims != nil {
+		if userID, err = claimID(claims, "id", "user_id", "sub"); err != nil {
+			return 0, "", 0, errors.New("token has no user id")
+		}
+		role, _ = claims["role"].(string)
+	}
+
+	allowed := claimBranches(claims)
+	if len(allowed) == 0 {
+		// Sin claims de sucursal solo se permite la sucursal por defecto
+		defaultID, err := defaultBranch()
+		if err != nil {
+			return 0, "", 0, err
+		}
+		if defaultID == 0 {
+			return 0, "", 0, errors.New("the shared catalog requires a token with a branch claim")
+		}
+		allowed = []uint{defaultID}
+	}
+
+	if requestedBranch == nil {
+		return userID, role, allowed[0], nil
+	}
+	for _, id := range allowed {
+		if id == *requestedBranch {
+			return userID, role, id, nil
+		}
+	}
+	return 0, "", 0, errors.New("the token does not grant access to the requested branch")
+}
+
+// managerApproval devuelve quién aprueba una operación que requiere a un encargado: el usuario de
+// managerToken si viene, que debe tener acceso a la sucursal de la petición, o si no el mismo
+// usuario de la petición. El rol sale siempre de un token verificado, nunca del payload.
+func managerApproval(audit models.AuditInfo, managerToken string) (models.Approval, error) {
+	if managerToken == "" {
+		return models.Approval{UserID: audit.UserID, Role: audit.Role}, nil
+	}
+	userID, role, _, err := authenticate(managerToken, &audit.BranchID)
+	if err != nil {
+		return models.Approval{}, fmt.Errorf("invalid manager token: %w", err)
+	}
+	return models.Approval{UserID: userID, Role: role}, nil
+}
+
+// claimBranches devuelve las sucursales que permite el token: primero la de "branch_id" y luego
+// las de "branches".
+func claimBranches(claims map[string]interface{}) []uint {
+	var branches []uint
+	if id, err := claimID(claims, "branch_id"); err == nil {
+		branches = append(branches, id)
+	}
+	list, _ := claims["branches"].([]interface{})
+	for _, value := range list {
+		if id, err := claimID(map[string]interface{}{"id": value}, "id"); err == nil {
+			branches = append(branches, id)
+		}
+	}
+	return branches
+}
+
+// defaultBranch devuelve la sucursal de las peticiones que no indican una.
+func defaultBranch() (uint, error) {
+	value := os.Getenv("DEFAULT_BRANCH_ID")
+	if value == "" {
+		return 1, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid DEFAULT_BRANCH_ID")
+	}
+	return uint(id), nil
+}
+
+// tokenClaims valida el token y devuelve sus claims; sin token devuelve nil.
+func tokenClaims(authorization string) (map[string]interface{}, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	if token == "" {
+		return nil, nil
+	}
+
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET environment variable missing")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("invalid token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.New("invalid token claims")
+	}
+	if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() > int64(exp) {
+		return nil, errors.New("token expired")
+	}
+	return claims, nil
+}
+
+// claimID devuelve el primero de los claims dados que tenga un ID, como número o como texto.
+func claimID(claims map[string]interface{}, keys ...string) (uint, error) {
+	for _, key := range keys {
+		switch v := claims[key].(type) {
+		case float64:
+			return uint(v), nil
+		case string:
+			if id, err := strconv.ParseUint(v, 10, 64); err == nil {
+				return uint(id), nil
+			}
+		}
+	}
+	return 0, errors.New("claim not found")
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
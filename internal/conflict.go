@@ -0,0 +1,17 @@
+package internal
+
+import (
+	"errors"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// conflictResponse marca como "Conflict" la respuesta de error cuando la comanda cambió mientras
+// se editaba, para que el cliente sepa que debe recargarla y reintentar.
+func conflictResponse(response models.Response, err error) models.Response {
+	if errors.Is(err, repository.ErrConflict) {
+		response.Message = "Conflict"
+	}
+	return response
+}
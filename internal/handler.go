@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"strconv"
 	"time"
 
 	//"github.com/ValeHenriquez/example-rabbit-go/tasks-server/controllers"
 	//"github.com/ValeHenriquez/example-rabbit-go/tasks-server/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/broker"
 	"github.com/FelipeGeraldoblufus/Comandas-ms/controllers"
 	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
@@ -19,7 +22,7 @@ func failOnError(err error, msg string) {
 	}
 }
 
-func Handler(d amqp.Delivery, ch *amqp.Channel) {
+func Handler(d amqp.Delivery, ch broker.Publisher) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -30,6 +33,7 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		Pattern string          `json:"pattern"`
 		Data    json.RawMessage `json:"data"`
 		ID      string          `json:"id"`
+		Headers models.Headers  `json:"headers"`
 	}
 	var err error
 	err = json.Unmarshal(d.Body, &Payload)
@@ -38,6 +42,20 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 
 	//dataJSON, err := json.Marshal(Payload.Data)
 	failOnError(err, "Failed to marshal data")
+
+	// Usuario y sucursal de la petición, para limitar los datos y auditar las escrituras
+	audit := models.AuditInfo{Pattern: actionType}
+	audit.UserID, audit.Role, audit.BranchID, err = authenticate(Payload.Headers.Authorization, Payload.Headers.Branch)
+	if err != nil {
+		log.Printf("Invalid authorization token: %v", err)
+		response = models.Response{
+			Success: "error",
+			Message: "Unauthorized",
+			Data:    []byte(err.Error()),
+		}
+		actionType = "" // No se procesa ningún patrón con un token inválido
+	}
+
 	switch actionType {
 	case "GET_PRODUCT":
 		log.Println(" [.] Getting product by ID")
@@ -46,25 +64,39 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		var productJson []byte
 		var product models.Product
 	
-		// Aquí, Payload.Data debería ser solo un número (ID)
+		// Payload.Data es {"id": ..., "locale": ...} o, como antes, solo el ID
 		log.Printf("Received Payload: %s", Payload.Data)
-	
-		// Convertir el Payload.Data a uint (ID del producto)
-		var productID string
-		if err := json.Unmarshal(Payload.Data, &productID); err != nil {
-			log.Printf("Error unmarshalling data: %v", err)
-			response = models.Response{
-				Success: "error",
-				Message: "Error parsing request data",
-				Data:    []byte(err.Error()),
+		var data struct {
+			ID     uint   `json:"id"`     // Producto
+			Locale string `json:"locale"` // Idioma de los textos (es por defecto)
+		}
+		if err := json.Unmarshal(Payload.Data, &data); err != nil {
+			var productID json.Number
+			if err := json.Unmarshal(Payload.Data, &productID); err != nil {
+				log.Printf("Error unmarshalling data: %v", err)
+				response = models.Response{
+					Success: "error",
+					Message: "Error parsing request data",
+					Data:    []byte(err.Error()),
+				}
+				break
 			}
-			break
+			id, err := strconv.ParseUint(productID.String(), 10, 64)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error parsing request data",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			data.ID = uint(id)
 		}
 	
-		log.Printf("Searching for product with ID: %d", productID)
+		log.Printf("Searching for product with ID: %d", data.ID)
 	
 		// Llamar a la función para obtener el producto por ID
-		product, err = controllers.GetByProductID(productID)
+		product, err = controllers.GetByProductID(audit.BranchID, data.ID, data.Locale)
 		if err != nil {
 			// Si no se encuentra el producto o ocurre otro error
 			log.Printf("Error getting product by ID: %v", err)
@@ -101,8 +133,9 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 			UpdateDTO struct {
 				Product        string `json:"product"`
 				NewNameProduct string `json:"newnameProduct"`
-				NewPrice       int    `json:"newPrice"`
+				NewPrice       money.Amount `json:"newPrice"`
 				NewDescription string `json:"newDescription"`
+				NewCategory    string `json:"newCategory"`
 			} `json:"updateOrderDTO"`
 		}
 	
@@ -141,10 +174,12 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 	
 		// Llamada a la función para actualizar el producto
 		producto, err = controllers.UpdateProduct(
+			audit,
 			data.UpdateDTO.Product, 
 			data.UpdateDTO.NewNameProduct, 
 			data.UpdateDTO.NewPrice,  
 			data.UpdateDTO.NewDescription, 
+			data.UpdateDTO.NewCategory,
 
 		)
 		if err != nil {
@@ -182,8 +217,9 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		// Estructura para deserializar los datos recibidos
 		var data struct {
 			Name        string  `json:"name"`
-			Price       int `json:"price"`
+			Price       money.Amount `json:"price"`
 			Description string  `json:"description"`
+			Category    string  `json:"category"`
 		}
 	
 		var err error
@@ -205,7 +241,7 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		}
 	
 		// Crear el producto utilizando los datos deserializados
-		product, err = controllers.CreateProduct(data.Name, data.Description, data.Price)
+		product, err = controllers.CreateProduct(audit, data.Name, data.Description, data.Price, data.Category)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
@@ -253,7 +289,7 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 			break
 		}
 
-		err = controllers.DeleteProductByName(data.Name)
+		err = controllers.DeleteProductByName(audit, data.Name)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
@@ -284,6 +320,8 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 			ProductID  uint `json:"product_id"`
 			Quantity   int  `json:"quantity"`
 			TableNumber int `json:"tablenumber"`
+			Course     int  `json:"course"` // Tiempo del menú; por defecto 1
+			Hold       bool `json:"hold"`   // Retener hasta disparar el tiempo con FIRE_COURSE o FIRE_ITEMS
 		}
 		var err error
 		var dataJson []byte
@@ -301,13 +339,14 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		}
 	
 		// Llamar al controlador
-		newItem, err = controllers.AddOrderItem(data.UserID, data.ProductID, data.Quantity, data.TableNumber)
+		newItem, err = controllers.AddOrderItem(audit, data.UserID, data.ProductID, data.Quantity, data.TableNumber, data.Course, data.Hold)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
 				Message: "Error creating order item",
 				Data:    []byte(err.Error()),
 			}
+			response = conflictResponse(response, err)
 			break
 		}
 	
@@ -332,9 +371,46 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		var err error
 		var dataJson []byte
 		var items []models.OrderItem
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			page, err := controllers.ListOrderItems(audit.BranchID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Order items fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
 	
 		// Llamar al controlador
-		items, err = controllers.GetAllOrderItems()
+		items, err = controllers.GetAllOrderItems(audit.BranchID)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
@@ -380,8 +456,46 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 			break
 		}
 	
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data, "user_id")
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			params.UserID = &data.UserID
+			page, err := controllers.ListOrderItems(audit.BranchID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Order Items retrieved successfully",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
 		// Llamar a la función del controlador para obtener los datos
-		items, err = controllers.GetOrderItemsByUserID(data.UserID)
+		items, err = controllers.GetOrderItemsByUserID(audit.BranchID, data.UserID)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
@@ -413,9 +527,46 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		var err error
 		var dataJson []byte
 		var order []models.Order
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			page, err := controllers.ListOrders(audit.BranchID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Orders fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
 	
 		// Llamar al controlador
-		order, err = controllers.GetAllOrders()
+		order, err = controllers.GetAllOrders(audit.BranchID)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
@@ -447,6 +598,7 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		var data struct {
 			Order_id uint    `json:"order_id"` // Número de mesa
 			NewStatus   string `json:"new_status"`   // Nuevo estado de la orden
+			Version     int    `json:"version"`      // Versión de la orden que vio el cliente (opcional)
 		}
 		
 		var err error
@@ -465,13 +617,14 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		}
 	
 		// Llamar a la función del controlador para actualizar el estado de la orden
-		updatedOrder, err = controllers.UpdateOrderStatus(data.Order_id, data.NewStatus)
+		updatedOrder, err = controllers.UpdateOrderStatus(audit, data.Order_id, data.NewStatus, data.Version)
 		if err != nil {
 			response = models.Response{
 				Success: "error",
 				Message: "Error updating order status",
 				Data:    []byte(err.Error()),
 			}
+			response = conflictResponse(response, err)
 			break
 		}
 	
@@ -515,13 +668,14 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		}
 	
 		// Eliminar el OrderItem usando el ID recibido
-		orderItem, err = controllers.DeleteOrderItem(data.OrderItemID) // Captura ambos valores
+		orderItem, err = controllers.DeleteOrderItem(audit, data.OrderItemID) // Captura ambos valores
 		if err != nil {
 			response = models.Response{
 				Success: "error",
 				Message: "Error Deleting OrderItem",
 				Data:    []byte(err.Error()),
 			}
+			response = conflictResponse(response, err)
 			break
 		}
 	
@@ -545,6 +699,2377 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 
 	
 
+	case "TRANSFER_ORDER":
+		log.Println(" [.] Transferring order to another table")
+		var data struct {
+			FromTable int `json:"from_table"` // Mesa actual
+			ToTable   int `json:"to_table"`   // Mesa de destino
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.TransferOrder(audit, data.FromTable, data.ToTable)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error transferring order",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order transferred",
+				Data:    dataJson,
+			}
+		}
+
+	case "MERGE_ORDERS":
+		log.Println(" [.] Merging table orders")
+		var data struct {
+			SourceTable int `json:"source_table"` // Mesa cuya orden se absorbe
+			TargetTable int `json:"target_table"` // Mesa que conserva la orden
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.MergeOrders(audit, data.SourceTable, data.TargetTable)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error merging orders",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Orders merged",
+				Data:    dataJson,
+			}
+		}
+
+	case "SPLIT_ORDER":
+		log.Println(" [.] Splitting order items")
+		var data struct {
+			OrderID uint   `json:"order_id"`       // Orden de origen
+			ItemIDs []uint `json:"order_item_ids"` // Items a separar
+			ToTable int    `json:"to_table"`       // Mesa que recibe los items
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.SplitOrder(audit, data.OrderID, data.ItemIDs, data.ToTable)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error splitting order",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order split",
+				Data:    dataJson,
+			}
+		}
+
+	case "VOID_ORDER_ITEM":
+		log.Println(" [.] Voiding OrderItem")
+		var data struct {
+			OrderItemID  uint   `json:"order_item_id"` // Item a modificar
+			ReasonCode   string `json:"reason_code"`   // Código del motivo
+			ManagerToken string `json:"manager_token"` // Token del encargado que aprueba; sin él aprueba el usuario de la petición
+			WasPrepared  bool   `json:"was_prepared"`  // Si el item ya se había preparado
+		}
+		var err error
+		var dataJson []byte
+		var orderItem *models.OrderItem
+		var approval models.Approval
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		approval, err = managerApproval(audit, data.ManagerToken)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error voiding OrderItem",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		orderItem, err = controllers.VoidOrderItem(audit, data.OrderItemID, data.ReasonCode, approval, data.WasPrepared)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error voiding OrderItem",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(orderItem)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "OrderItem voided",
+				Data:    dataJson,
+			}
+		}
+
+	case "COMP_ORDER_ITEM":
+		log.Println(" [.] Comping OrderItem")
+		var data struct {
+			OrderItemID  uint   `json:"order_item_id"` // Item a modificar
+			ReasonCode   string `json:"reason_code"`   // Código del motivo
+			ManagerToken string `json:"manager_token"` // Token del encargado que aprueba; sin él aprueba el usuario de la petición
+			WasPrepared  bool   `json:"was_prepared"`  // Si el item ya se había preparado
+		}
+		var err error
+		var dataJson []byte
+		var orderItem *models.OrderItem
+		var approval models.Approval
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		approval, err = managerApproval(audit, data.ManagerToken)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error comping OrderItem",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		orderItem, err = controllers.CompOrderItem(audit, data.OrderItemID, data.ReasonCode, approval, data.WasPrepared)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error comping OrderItem",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(orderItem)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "OrderItem comped",
+				Data:    dataJson,
+			}
+		}
+
+	case "UPDATE_ORDER_ITEM":
+		log.Println(" [.] Updating OrderItem")
+		var data struct {
+			OrderItemID uint     `json:"order_item_id"` // Item a modificar
+			Quantity    int      `json:"quantity"`      // Nueva cantidad
+			Notes       *string  `json:"notes"`         // Nuevas notas (opcional)
+			Modifiers   []string `json:"modifiers"`     // Nuevos modificadores (opcional)
+			Force        bool     `json:"force"`         // Forzar el cambio de un item en preparación
+			ManagerToken string   `json:"manager_token"` // Token del encargado que autoriza el cambio forzado; sin él autoriza el usuario de la petición
+		}
+		var err error
+		var dataJson []byte
+		var orderItem *models.OrderItem
+		var approval models.Approval
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		approval, err = managerApproval(audit, data.ManagerToken)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error updating OrderItem",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		orderItem, err = controllers.UpdateOrderItem(audit, data.OrderItemID, data.Quantity, data.Notes, data.Modifiers, data.Force, approval)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error updating OrderItem",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(orderItem)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "OrderItem updated",
+				Data:    dataJson,
+			}
+		}
+
+	case "UPDATE_KITCHEN_STATUS":
+		log.Println(" [.] Updating OrderItem kitchen status")
+		var data struct {
+			OrderItemID   uint   `json:"order_item_id"`  // Item a modificar
+			KitchenStatus string `json:"kitchen_status"` // Recibido, En preparacion o Listo
+		}
+		var err error
+		var dataJson []byte
+		var orderItem *models.OrderItem
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		orderItem, err = controllers.UpdateKitchenStatus(audit, data.OrderItemID, data.KitchenStatus)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error updating kitchen status",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(orderItem)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Kitchen status updated",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_AUDIT_LOG":
+		log.Println(" [.] Getting audit log")
+		var data struct {
+			Entity   string     `json:"entity"`    // Product, Order u OrderItem
+			EntityID uint       `json:"entity_id"` // Identificador de la entidad
+			UserID   *uint      `json:"user_id"`   // Usuario que hizo los cambios
+			From     *time.Time `json:"from"`      // Fecha desde (RFC3339)
+			To       *time.Time `json:"to"`        // Fecha hasta (RFC3339)
+		}
+		var err error
+		var dataJson []byte
+		var logs []models.AuditLog
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data, "entity", "entity_id", "user_id", "from", "to")
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			page, err := controllers.ListAuditLogs(audit.BranchID, data.Entity, data.EntityID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Audit log fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
+		logs, err = controllers.GetAuditLogs(audit.BranchID, data.Entity, data.EntityID, data.UserID, data.From, data.To)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching audit log",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(logs)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Audit log fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_ALL_PRODUCTS":
+		log.Println(" [.] Getting all products")
+		var data struct {
+			IncludeArchived bool   `json:"include_archived"` // Incluir productos archivados
+			Locale          string `json:"locale"`           // Idioma del menú (es por defecto)
+		}
+		var err error
+		var dataJson []byte
+		var products []models.Product
+
+		// El payload es opcional
+		if len(Payload.Data) > 0 && string(Payload.Data) != "null" {
+			err = json.Unmarshal(Payload.Data, &data)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error decoding JSON",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+		}
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data, "include_archived", "locale")
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			page, err := controllers.ListProducts(audit.BranchID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Products fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
+		products, err = controllers.GetAllProducts(audit.BranchID, data.IncludeArchived, data.Locale)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching products",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(products)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Products fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "RESTORE_PRODUCT":
+		log.Println(" [.] Restoring archived product")
+		var data struct {
+			Name string `json:"name"`
+		}
+		var err error
+		var dataJson []byte
+		var product models.Product
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		product, err = controllers.RestoreProduct(audit, data.Name)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error restoring product",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(product)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Product restored",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_PRODUCT_PRICE_HISTORY":
+		log.Println(" [.] Getting product price history")
+		var data struct {
+			Name string `json:"name"` // Nombre del producto
+		}
+		var err error
+		var dataJson []byte
+		var history []models.ProductPrice
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data, "name")
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			page, err := controllers.ListPriceHistory(audit.BranchID, data.Name, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Price history fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
+		history, err = controllers.GetPriceHistory(audit.BranchID, data.Name)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching price history",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(history)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Price history fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_SALES_REPORT":
+		log.Println(" [.] Getting sales report")
+		var data struct {
+			From   time.Time `json:"from"`   // Inicio del periodo (RFC3339)
+			To     time.Time `json:"to"`     // Fin del periodo, excluido (RFC3339)
+			Bucket string    `json:"bucket"` // hour, day o week
+		}
+		var err error
+		var dataJson []byte
+		var report []models.SalesBucket
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		report, err = controllers.GetSalesReport(audit.BranchID, data.From, data.To, data.Bucket)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error building sales report",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(report)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Sales report built",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_PRODUCT_SALES":
+		log.Println(" [.] Getting sales per product")
+		var data struct {
+			From    time.Time `json:"from"`     // Inicio del periodo (RFC3339)
+			To      time.Time `json:"to"`       // Fin del periodo, excluido (RFC3339)
+			GroupBy string    `json:"group_by"` // product o category
+			Limit   int       `json:"limit"`    // Cantidad máxima de filas
+		}
+		var err error
+		var dataJson []byte
+		var sales []models.ProductSales
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		sales, err = controllers.GetProductSales(audit.BranchID, data.From, data.To, data.GroupBy, data.Limit)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error building product sales",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(sales)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Product sales built",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_TOP3POPULARPRODUCTS":
+		log.Println(" [.] Getting top 3 popular products")
+		var err error
+		var dataJson []byte
+		var sales []models.ProductSales
+
+		sales, err = controllers.GetTop3PopularProducts(audit.BranchID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting products",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(sales)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Products retrieved",
+				Data:    dataJson,
+			}
+		}
+
+	case "SET_ORDER_COVERS":
+		log.Println(" [.] Setting order covers")
+		var data struct {
+			OrderID uint `json:"order_id"` // Comanda
+			Covers  int  `json:"covers"`   // Cantidad de comensales
+			Version int  `json:"version"`  // Versión de la comanda que vio el cliente (opcional)
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.SetOrderCovers(audit, data.OrderID, data.Covers, data.Version)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error setting covers",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order covers updated",
+				Data:    dataJson,
+			}
+		}
+
+	case "OPEN_SHIFT":
+		log.Println(" [.] Opening cash register shift")
+		var data struct {
+			StartingCash money.Amount `json:"starting_cash"` // Efectivo inicial en caja
+		}
+		var err error
+		var dataJson []byte
+		var shift *models.Shift
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		shift, err = controllers.OpenShift(audit, data.StartingCash)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error opening shift",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(shift)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Shift opened",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_CURRENT_SHIFT":
+		log.Println(" [.] Getting current shift")
+		var err error
+		var dataJson []byte
+		var shift *models.Shift
+
+		shift, err = controllers.GetCurrentShift(audit.BranchID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting current shift",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(shift)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Current shift retrieved",
+				Data:    dataJson,
+			}
+		}
+
+	case "REGISTER_PAYMENT":
+		log.Println(" [.] Registering payment")
+		var data struct {
+			OrderID uint   `json:"order_id"` // Comanda que se paga
+			Tender  string `json:"tender"`   // Efectivo, Debito, Credito o Transferencia
+			Amount  money.Amount `json:"amount"`   // Monto aplicado a la comanda
+			Tip     money.Amount `json:"tip"`      // Propina
+		}
+		var err error
+		var dataJson []byte
+		var payment *models.Payment
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		payment, err = controllers.RegisterPayment(audit, data.OrderID, data.Tender, data.Amount, data.Tip)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error registering payment",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(payment)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Payment registered",
+				Data:    dataJson,
+			}
+		}
+
+	case "CLOSE_SHIFT":
+		log.Println(" [.] Closing cash register shift")
+		var data struct {
+			Force bool `json:"force"` // Cerrar aunque queden comandas pendientes
+		}
+		var err error
+		var dataJson []byte
+		var report *models.ZReport
+
+		// El payload es opcional
+		if len(Payload.Data) > 0 && string(Payload.Data) != "null" {
+			err = json.Unmarshal(Payload.Data, &data)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error decoding JSON",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+		}
+
+		report, err = controllers.CloseShift(audit, data.Force)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error closing shift",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(report)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Shift closed",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_Z_REPORT":
+		log.Println(" [.] Getting Z report")
+		var data struct {
+			ShiftID uint `json:"shift_id"` // Turno cerrado
+		}
+		var err error
+		var dataJson []byte
+		var report *models.ZReport
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		report, err = controllers.GetZReport(audit.BranchID, data.ShiftID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting Z report",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(report)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Z report retrieved",
+				Data:    dataJson,
+			}
+		}
+
+	case "IMPORT_PRODUCTS":
+		log.Println(" [.] Importing product catalog")
+		var data struct {
+			Format  string `json:"format"`  // csv o json
+			Content string `json:"content"` // Contenido del archivo
+			DryRun  bool   `json:"dry_run"` // Solo informar los cambios, sin guardarlos
+		}
+		var err error
+		var dataJson []byte
+		var result *models.ImportResult
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		result, err = controllers.ImportProducts(audit, data.Format, []byte(data.Content), data.DryRun)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error importing products",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(result)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Products imported",
+				Data:    dataJson,
+			}
+		}
+
+	case "EXPORT_PRODUCTS":
+		log.Println(" [.] Exporting product catalog")
+		var data struct {
+			Format string `json:"format"` // csv o json
+		}
+		var err error
+		var dataJson []byte
+		var content []byte
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		content, err = controllers.ExportCatalog(audit.BranchID, data.Format)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error exporting products",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		// El contenido se devuelve como texto, en el mismo formato que acepta IMPORT_PRODUCTS
+		dataJson, err = json.Marshal(map[string]string{
+			"format":  data.Format,
+			"content": string(content),
+		})
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Products exported",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_ORDER":
+		log.Println(" [.] Getting order by ID")
+		var data struct {
+			OrderID uint `json:"order_id"` // Comanda
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.GetOrderByID(audit.BranchID, data.OrderID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting order",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order retrieved",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_ORDER_BY_TABLE":
+		log.Println(" [.] Getting open order by table")
+		var data struct {
+			TableNumber int `json:"table_number"` // Número de mesa
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.GetOrderByTable(audit.BranchID, data.TableNumber)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting order",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order retrieved",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_ACTIVE_ORDERS":
+		log.Println(" [.] Getting active orders")
+		var err error
+		var dataJson []byte
+		var orders []models.Order
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			params.Status = "Pendiente"
+			if params.SortBy == "" {
+				params.SortBy = "table_number"
+			}
+			page, err := controllers.ListOrders(audit.BranchID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Orders fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
+		orders, err = controllers.GetActiveOrders(audit.BranchID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching orders",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(orders)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Orders fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_ORDERS_BY_USER":
+		log.Println(" [.] Getting orders by user")
+		var data struct {
+			UserID uint `json:"user_id"` // Usuario asignado
+		}
+		var err error
+		var dataJson []byte
+		var orders []models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data, "user_id")
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			params.UserID = &data.UserID
+			if params.SortDir == "" {
+				params.SortDir = "desc"
+			}
+			page, err := controllers.ListOrders(audit.BranchID, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Orders fetched",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
+		orders, err = controllers.GetOrdersByUser(audit.BranchID, data.UserID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching orders",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(orders)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Orders fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "SEARCH_PRODUCTS":
+		log.Println(" [.] Searching products")
+		var data struct {
+			Query           string `json:"query"`            // Texto a buscar
+			Limit           int    `json:"limit"`            // Cantidad máxima de resultados
+			IncludeArchived bool   `json:"include_archived"` // Incluir productos archivados
+			Locale          string `json:"locale"`           // Idioma de los resultados (es por defecto)
+
+			ExcludeAllergens []string `json:"exclude_allergens"` // Excluir productos con estos alérgenos
+			DietaryTags      []string `json:"dietary_tags"`      // Solo productos con todas estas etiquetas
+		}
+		var err error
+		var dataJson []byte
+		var results []models.ProductSearchResult
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		results, err = controllers.SearchProducts(audit.BranchID, data.Query, data.Limit, data.IncludeArchived, data.Locale, data.ExcludeAllergens, data.DietaryTags)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error searching products",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(results)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Products found",
+				Data:    dataJson,
+			}
+		}
+
+	case "SET_PRODUCT_TRANSLATION":
+		log.Println(" [.] Setting product translation")
+		var data struct {
+			Product     string `json:"product"`     // Nombre del producto en español
+			Locale      string `json:"locale"`      // Idioma de la traducción
+			Name        string `json:"name"`        // Nombre traducido
+			Description string `json:"description"` // Descripción traducida
+		}
+		var err error
+		var dataJson []byte
+		var translation *models.ProductTranslation
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		translation, err = controllers.SetProductTranslation(audit, data.Product, data.Locale, data.Name, data.Description)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error saving translation",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(translation)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Translation saved",
+				Data:    dataJson,
+			}
+		}
+
+	case "DELETE_PRODUCT_TRANSLATION":
+		log.Println(" [.] Deleting product translation")
+		var data struct {
+			Product string `json:"product"` // Nombre del producto en español
+			Locale  string `json:"locale"`  // Idioma de la traducción
+		}
+		var err error
+		var dataJson []byte
+		var translation *models.ProductTranslation
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		translation, err = controllers.DeleteProductTranslation(audit, data.Product, data.Locale)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error deleting translation",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(translation)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Translation deleted",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_PRODUCT_TRANSLATIONS":
+		log.Println(" [.] Getting product translations")
+		var data struct {
+			Product string `json:"product"` // Nombre del producto en español
+		}
+		var err error
+		var dataJson []byte
+		var translations []models.ProductTranslation
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		translations, err = controllers.GetProductTranslations(audit.BranchID, data.Product)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching translations",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(translations)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Translations fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "SET_CATEGORY_TRANSLATION":
+		log.Println(" [.] Setting category translation")
+		var data struct {
+			Category string `json:"category"` // Categoría en español
+			Locale   string `json:"locale"`   // Idioma de la traducción
+			Name     string `json:"name"`     // Nombre traducido
+		}
+		var err error
+		var dataJson []byte
+		var translation *models.CategoryTranslation
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		translation, err = controllers.SetCategoryTranslation(audit, data.Category, data.Locale, data.Name)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error saving translation",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(translation)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Translation saved",
+				Data:    dataJson,
+			}
+		}
+
+	case "UPLOAD_PRODUCT_IMAGE":
+		log.Println(" [.] Uploading product image")
+		var data struct {
+			Product string `json:"product"` // Nombre del producto
+			Image   string `json:"image"`   // Imagen en base64 o data URL
+		}
+		var err error
+		var dataJson []byte
+		var image *models.ProductImage
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		image, err = controllers.AddProductImageBase64(audit, data.Product, data.Image)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error uploading image",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(image)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Image uploaded",
+				Data:    dataJson,
+			}
+		}
+
+	case "DELETE_PRODUCT_IMAGE":
+		log.Println(" [.] Deleting product image")
+		var data struct {
+			ImageID uint `json:"image_id"` // Imagen a borrar
+		}
+		var err error
+		var dataJson []byte
+		var image *models.ProductImage
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		image, err = controllers.DeleteProductImage(audit, data.ImageID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error deleting image",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(image)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Image deleted",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_PRODUCT_IMAGES":
+		log.Println(" [.] Getting product images")
+		var data struct {
+			Product string `json:"product"` // Nombre del producto
+		}
+		var err error
+		var dataJson []byte
+		var images []models.ProductImage
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		images, err = controllers.GetProductImages(audit.BranchID, data.Product)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching images",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(images)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Images fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "SET_PRODUCT_DIETARY":
+		log.Println(" [.] Setting product allergens and dietary tags")
+		var data struct {
+			Product     string   `json:"product"`      // Nombre del producto
+			Allergens   []string `json:"allergens"`    // Alérgenos que contiene
+			DietaryTags []string `json:"dietary_tags"` // Etiquetas de dieta
+		}
+		var err error
+		var dataJson []byte
+		var product models.Product
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		product, err = controllers.SetProductDietary(audit, data.Product, data.Allergens, data.DietaryTags)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error updating product",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(product)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Product updated",
+				Data:    dataJson,
+			}
+		}
+
+	case "SET_ORDER_ALLERGIES":
+		log.Println(" [.] Setting guest allergies for table")
+		var data struct {
+			TableNumber int      `json:"table_number"` // Número de mesa
+			Allergies   []string `json:"allergies"`    // Alergias de los comensales
+			Version     int      `json:"version"`      // Versión de la comanda que vio el cliente (opcional)
+		}
+		var err error
+		var dataJson []byte
+		var order *models.Order
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		order, err = controllers.SetOrderAllergies(audit, data.TableNumber, data.Allergies, data.Version)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error updating order allergies",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(order)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order allergies updated",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_CURRENCY":
+		log.Println(" [.] Getting currency settings")
+		dataJson, err := json.Marshal(controllers.GetCurrencySettings(audit.BranchID))
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Currency fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "CREATE_BRANCH":
+		log.Println(" [.] Creating branch")
+		var data struct {
+			Name     string `json:"name"`     // Nombre de la sucursal
+			Currency string `json:"currency"` // Moneda; vacía usa la configurada
+		}
+		var err error
+		var dataJson []byte
+		var branch *models.Branch
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		branch, err = controllers.CreateBranch(audit, data.Name, data.Currency)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error creating branch",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(branch)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Branch created",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_BRANCHES":
+		log.Println(" [.] Getting branches")
+		branches, err := controllers.GetBranches(audit.BranchID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error fetching branches",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err := json.Marshal(branches)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Branches fetched",
+				Data:    dataJson,
+			}
+		}
+
+	case "SET_PRODUCT_OVERRIDE":
+		log.Println(" [.] Setting branch product override")
+		var data struct {
+			Product string        `json:"product"` // Nombre del producto del catálogo común
+			Price   *money.Amount `json:"price"`   // Precio en la sucursal; null usa el del catálogo
+			Hidden  bool          `json:"hidden"`  // Si la sucursal no lo ofrece
+		}
+		var err error
+		var dataJson []byte
+		var override *models.ProductOverride
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		override, err = controllers.SetProductOverride(audit, data.Product, data.Price, data.Hidden)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error setting product override",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(override)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Product override saved",
+				Data:    dataJson,
+			}
+		}
+
+	case "DELETE_PRODUCT_OVERRIDE":
+		log.Println(" [.] Deleting branch product override")
+		var data struct {
+			Product string `json:"product"` // Nombre del producto del catálogo común
+		}
+		var err error
+		var dataJson []byte
+		var override *models.ProductOverride
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		override, err = controllers.DeleteProductOverride(audit, data.Product)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error deleting product override",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(override)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Product override deleted",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_RECEIPT":
+		log.Println(" [.] Generating receipt")
+		var data struct {
+			OrderID     uint   `json:"order_id"`     // Comanda; si es 0 se usa la pendiente de la mesa
+			TableNumber int    `json:"table_number"` // Mesa, si no se indica la comanda
+			Format      string `json:"format"`       // text, escpos o pdf
+			Width       string `json:"width"`        // 58mm u 80mm
+		}
+		var err error
+		var dataJson []byte
+		var receipt *models.Receipt
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		receipt, err = controllers.GetReceipt(audit.BranchID, data.OrderID, data.TableNumber, data.Format, data.Width)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error generating receipt",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(receipt)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Receipt generated",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_PRINT_JOBS":
+		log.Println(" [.] Getting print jobs")
+		var data struct {
+			Estado string `json:"estado"` // Pendiente, Impreso o Fallido; por defecto Fallido
+		}
+		var err error
+		var dataJson []byte
+		var jobs []models.PrintJob
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		// Con parámetros de paginación se responde una página en lugar del listado completo
+		params, err := pageRequest(Payload.Data, "estado")
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+		if params != nil {
+			page, err := controllers.ListPrintJobs(audit.BranchID, data.Estado, *params)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error fetching page",
+					Data:    []byte(err.Error()),
+				}
+				break
+			}
+			dataJson, err = json.Marshal(page)
+			if err != nil {
+				response = models.Response{
+					Success: "error",
+					Message: "Error marshaling JSON",
+					Data:    []byte(err.Error()),
+				}
+			} else {
+				response = models.Response{
+					Success: "success",
+					Message: "Print jobs retrieved",
+					Data:    dataJson,
+				}
+			}
+			break
+		}
+
+		jobs, err = controllers.GetPrintJobs(audit.BranchID, data.Estado)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting print jobs",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(jobs)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Print jobs retrieved",
+				Data:    dataJson,
+			}
+		}
+
+	case "RETRY_PRINT_JOB":
+		log.Println(" [.] Retrying print job")
+		var data struct {
+			JobID uint `json:"job_id"` // Trabajo pendiente o fallido
+		}
+		var err error
+		var dataJson []byte
+		var job *models.PrintJob
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		job, err = controllers.RetryPrintJob(audit, data.JobID)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error printing job",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(job)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Print job printed",
+				Data:    dataJson,
+			}
+		}
+
+	case "REPRINT_PRINT_JOB":
+		log.Println(" [.] Reprinting print job")
+		var data struct {
+			JobID   uint   `json:"job_id"`  // Trabajo impreso o fallido
+			Station string `json:"station"` // Estación que lo imprime; por defecto la del trabajo
+		}
+		var err error
+		var dataJson []byte
+		var job *models.PrintJob
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		job, err = controllers.ReprintPrintJob(audit, data.JobID, data.Station)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error reprinting job",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(job)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Print job queued",
+				Data:    dataJson,
+			}
+		}
+
+	case "FIRE_COURSE":
+		log.Println(" [.] Firing course to the kitchen")
+		var data struct {
+			TableNumber int `json:"table_number"` // Mesa de la comanda pendiente
+			Course      int `json:"course"`       // Tiempo del menú que se envía
+		}
+		var err error
+		var dataJson []byte
+		var items []models.OrderItem
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		items, err = controllers.FireCourse(audit, data.TableNumber, data.Course)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error firing course",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(items)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Course fired",
+				Data:    dataJson,
+			}
+		}
+
+	case "FIRE_ITEMS":
+		log.Println(" [.] Firing order items to the kitchen")
+		var data struct {
+			OrderItemIDs []uint `json:"order_item_ids"` // Items retenidos que se envían
+		}
+		var err error
+		var dataJson []byte
+		var items []models.OrderItem
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		items, err = controllers.FireItems(audit, data.OrderItemIDs)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error firing order items",
+				Data:    []byte(err.Error()),
+			}
+			response = conflictResponse(response, err)
+			break
+		}
+
+		dataJson, err = json.Marshal(items)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Order items fired",
+				Data:    dataJson,
+			}
+		}
+
+	case "GET_KITCHEN_ITEMS":
+		log.Println(" [.] Getting kitchen items")
+		var data struct {
+			Station string `json:"station"` // Estación (cocina, barra...); vacío muestra todas
+		}
+		var err error
+		var dataJson []byte
+		var items []models.OrderItem
+
+		err = json.Unmarshal(Payload.Data, &data)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error decoding JSON",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		items, err = controllers.GetKitchenItems(audit.BranchID, data.Station)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error getting kitchen items",
+				Data:    []byte(err.Error()),
+			}
+			break
+		}
+
+		dataJson, err = json.Marshal(items)
+		if err != nil {
+			response = models.Response{
+				Success: "error",
+				Message: "Error marshaling JSON",
+				Data:    []byte(err.Error()),
+			}
+		} else {
+			response = models.Response{
+				Success: "success",
+				Message: "Kitchen items retrieved",
+				Data:    dataJson,
+			}
+		}
+
 	/*case "CREATE_CARTITEM":
 		log.Println(" [.] Creating cartitem")
 		var data struct {
@@ -972,7 +3497,7 @@ func Handler(d amqp.Delivery, ch *amqp.Channel) {
 		/*case "GET_TOP3POPULARPRODUCTS":
 		log.Println(" [.] Getting top 3 popular products")
 
-		products, err := controllers.GetTop3PopularProducts()
+		products, err := controllers.GetTop3PopularProducts(audit.BranchID)
 		failOnError(err, "Failed to get products")
 		productsJSON, err := json.Marshal(products)
 		failOnError(err, "Failed to marshal products")
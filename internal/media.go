@@ -0,0 +1,82 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	db "github.com/FelipeGeraldoblufus/Comandas-ms/config"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/controllers"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/media"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+)
+
+// ServeMedia levanta el servidor HTTP de imágenes de productos:
+//
+//	POST /products/images  sube una imagen (multipart con los campos "product" e "image"; requiere token)
+//	GET  /media/...        sirve los archivos del almacenamiento local
+func ServeMedia(port string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/products/images", uploadImageHandler)
+	if local, ok := db.Storage.(*media.LocalStorage); ok {
+		mux.Handle("/media/", http.StripPrefix("/media/", http.FileServer(http.Dir(local.Dir))))
+	}
+
+	log.Printf(" [*] Serving media on port %s", port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		log.Printf("Media server stopped: %v", err)
+	}
+}
+
+func uploadImageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Las subidas escriben en disco: solo con un token válido, y en la sucursal que indica
+	userID, role, branchID, err := authenticate(r.Header.Get("Authorization"), nil)
+	if err == nil && userID == 0 {
+		err = errors.New("a token is required to upload images")
+	}
+	if err != nil {
+		writeMediaResponse(w, http.StatusUnauthorized, models.Response{Success: "error", Message: "Unauthorized", Data: []byte(err.Error())})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, controllers.MaxImageSize+1<<20)
+	if err := r.ParseMultipartForm(controllers.MaxImageSize); err != nil {
+		writeMediaResponse(w, http.StatusBadRequest, models.Response{Success: "error", Message: "Error parsing form", Data: []byte(err.Error())})
+		return
+	}
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		writeMediaResponse(w, http.StatusBadRequest, models.Response{Success: "error", Message: "Image is required", Data: []byte(err.Error())})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		writeMediaResponse(w, http.StatusBadRequest, models.Response{Success: "error", Message: "Error reading image", Data: []byte(err.Error())})
+		return
+	}
+
+	audit := models.AuditInfo{UserID: userID, Role: role, BranchID: branchID, Pattern: "HTTP_UPLOAD_PRODUCT_IMAGE"}
+	image, err := controllers.AddProductImage(audit, r.FormValue("product"), data)
+	if err != nil {
+		writeMediaResponse(w, http.StatusUnprocessableEntity, models.Response{Success: "error", Message: "Error uploading image", Data: []byte(err.Error())})
+		return
+	}
+
+	imageJson, _ := json.Marshal(image)
+	writeMediaResponse(w, http.StatusCreated, models.Response{Success: "success", Message: "Image uploaded", Data: imageJson})
+}
+
+func writeMediaResponse(w http.ResponseWriter, status int, response models.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"encoding/json"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+)
+
+// pageRequest decodifica los parámetros de paginación y filtros de un patrón de listado.
+// Devuelve nil si la petición no trae ninguno más allá de legacyKeys, para que los clientes
+// antiguos sigan recibiendo el arreglo completo como antes.
+func pageRequest(raw json.RawMessage, legacyKeys ...string) (*models.ListParams, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range legacyKeys {
+		delete(fields, key)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	var params models.ListParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
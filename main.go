@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	//"github.com/ValeHenriquez/example-rabbit-go/users-server/config"
 	//"github.com/ValeHenriquez/example-rabbit-go/users-server/internal"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/broker"
 	"github.com/FelipeGeraldoblufus/Comandas-ms/config"
 	"github.com/FelipeGeraldoblufus/Comandas-ms/internal"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/printing"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
 	"github.com/joho/godotenv"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
@@ -26,7 +32,7 @@ func getChannel() *amqp.Channel {
 	return ch
 }
 
-func declareQueue(ch *amqp.Channel) amqp.Queue {
+func declareQueue(ch broker.Channel) amqp.Queue {
 	q, err := ch.QueueDeclare(
 		"orders", // name
 		true,  // durable
@@ -40,7 +46,7 @@ func declareQueue(ch *amqp.Channel) amqp.Queue {
 }
 
 // Establece la calidad de servicio (QoS) para el canal de RabbitMQ.
-func setQoS(ch *amqp.Channel) {
+func setQoS(ch broker.Channel) {
 	err := ch.Qos(
 		1,     // prefetch count: Especifica cuántos mensajes puede recibir un consumidor antes de que se detenga la entrega. En este caso, se establece en 1.
 		0,     // prefetch size: No se usa en este caso, se establece como 0.
@@ -51,7 +57,7 @@ func setQoS(ch *amqp.Channel) {
 }
 
 // Registra un consumidor para la cola dada y devuelve un canal de entrega de mensajes.
-func registerConsumer(ch *amqp.Channel, q amqp.Queue) <-chan amqp.Delivery {
+func registerConsumer(ch broker.Channel, q amqp.Queue) <-chan amqp.Delivery {
 	msgs, err := ch.Consume(
 		q.Name, // queue
 		"",     // consumer
@@ -67,6 +73,12 @@ func registerConsumer(ch *amqp.Channel, q amqp.Queue) <-chan amqp.Delivery {
 
 func main() {
 
+	// Subcomandos de línea de comandos (importar/exportar catálogo, migraciones)
+	if len(os.Args) > 1 {
+		godotenv.Load()
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	fmt.Println("Users MS starting...")
 
 	godotenv.Load()
@@ -75,9 +87,25 @@ func main() {
 	config.SetupDatabase()
 	fmt.Println("Database connection configured...")
 
+	config.SetupCurrency()
+	fmt.Println("Currency configured:", config.Currency.Code)
+	config.SetupReceipt()
+
+	config.SetupPrinting()
+	if len(config.Printing.Printers) > 0 {
+		worker := &printing.Worker{Store: repository.NewGormStore(config.DB), Settings: config.Printing, Interval: time.Second}
+		go worker.Run(context.Background()) // Envía los tickets de cocina a las impresoras de las estaciones
+		fmt.Println("Kitchen printers configured for branches:", len(config.Printing.Printers))
+	}
+
 	config.SetupRabbitMQ()
 	fmt.Println("RabbitMQ Connection configured...")
 
+	config.SetupStorage()
+	if port := os.Getenv("PORT"); port != "" {
+		go internal.ServeMedia(port) // Servidor HTTP para subir y servir imágenes de productos
+	}
+
 	ch := getChannel()              // Obtiene un canal de RabbitMQ
 	q := declareQueue(ch)           // Declara una cola y obtiene su estructura
 	setQoS(ch)                      // Establece la calidad de servicio en el canal
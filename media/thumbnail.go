@@ -0,0 +1,86 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Registra el decodificador GIF
+	"image/jpeg"
+	_ "image/png" // Registra el decodificador PNG
+)
+
+// ThumbnailWidth es el ancho máximo de las miniaturas generadas.
+const ThumbnailWidth = 320
+
+// MaxPixels es el máximo de píxeles (ancho × alto) de una imagen subida. Un archivo pequeño puede
+// declarar dimensiones enormes y agotar la memoria al decodificarlo, por eso se revisan antes.
+const MaxPixels = 40_000_000
+
+// Thumbnail decodifica una imagen JPEG, PNG o GIF y devuelve una miniatura JPEG de ancho máximo
+// maxWidth, manteniendo la proporción. También devuelve el formato detectado de la imagen original.
+func Thumbnail(data []byte, maxWidth int) ([]byte, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, "", fmt.Errorf("image dimensions %dx%d exceed the limit of %d pixels", config.Width, config.Height, MaxPixels)
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxWidth {
+		height = height * maxWidth / width
+		width = maxWidth
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	dst := resize(src, width, height)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), format, nil
+}
+
+// resize reduce la imagen promediando los píxeles de origen que caen en cada píxel de destino.
+func resize(src image.Image, width int, height int) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sw, sh := bounds.Dx(), bounds.Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*sh/height
+		y1 := bounds.Min.Y + (y+1)*sh/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*sw/width
+			x1 := bounds.Min.X + (x+1)*sw/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{uint16(r / n), uint16(g / n), uint16(b / n), uint16(a / n)})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,29 @@
+-- Esquema inicial (productos, comandas e items). Usa IF NOT EXISTS para poder aplicarse sobre
+-- bases creadas antes con AutoMigrate.
+CREATE TABLE IF NOT EXISTS products (
+    id          bigserial PRIMARY KEY,
+    name        text   NOT NULL UNIQUE,
+    description text   NOT NULL,
+    price       bigint NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id           bigserial PRIMARY KEY,
+    table_number bigint      NOT NULL,
+    user_id      bigint      NOT NULL,
+    order_date   timestamptz NOT NULL,
+    total_amount bigint      NOT NULL,
+    estado       text        NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint NOT NULL,
+    order_id     bigint REFERENCES orders (id),
+    product_id   bigint NOT NULL REFERENCES products (id),
+    quantity     bigint NOT NULL,
+    total_price  bigint NOT NULL,
+    table_number bigint NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
@@ -0,0 +1,17 @@
+ALTER TABLE order_items
+    DROP CONSTRAINT IF EXISTS chk_order_items_quantity,
+    DROP CONSTRAINT IF EXISTS chk_order_items_kitchen_status,
+    DROP CONSTRAINT IF EXISTS chk_order_items_estado,
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS product_name,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS kitchen_status,
+    DROP COLUMN IF EXISTS modifiers,
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS voided_at,
+    DROP COLUMN IF EXISTS was_prepared,
+    DROP COLUMN IF EXISTS approved_by,
+    DROP COLUMN IF EXISTS voided_by,
+    DROP COLUMN IF EXISTS reason_code,
+    DROP COLUMN IF EXISTS estado;
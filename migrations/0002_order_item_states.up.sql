@@ -0,0 +1,27 @@
+-- Anulaciones y cortesías, edición de items, estado en cocina y copia de nombre y precio del producto.
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS estado         text    NOT NULL DEFAULT 'Activo',
+    ADD COLUMN IF NOT EXISTS reason_code    text,
+    ADD COLUMN IF NOT EXISTS voided_by      bigint,
+    ADD COLUMN IF NOT EXISTS approved_by    bigint,
+    ADD COLUMN IF NOT EXISTS was_prepared   boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS voided_at      timestamptz,
+    ADD COLUMN IF NOT EXISTS notes          text,
+    ADD COLUMN IF NOT EXISTS modifiers      text,
+    ADD COLUMN IF NOT EXISTS kitchen_status text    NOT NULL DEFAULT 'Recibido',
+    ADD COLUMN IF NOT EXISTS created_at     timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at     timestamptz,
+    ADD COLUMN IF NOT EXISTS product_name   text,
+    ADD COLUMN IF NOT EXISTS unit_price     bigint  NOT NULL DEFAULT 0;
+
+-- Los items antiguos toman el nombre actual del producto y el precio que se cobró
+UPDATE order_items oi
+SET product_name = p.name,
+    unit_price   = CASE WHEN oi.quantity > 0 THEN oi.total_price / oi.quantity ELSE p.price END
+FROM products p
+WHERE p.id = oi.product_id AND (oi.product_name IS NULL OR oi.product_name = '');
+
+ALTER TABLE order_items
+    ADD CONSTRAINT chk_order_items_estado CHECK (estado IN ('Activo', 'Anulado', 'Cortesia')),
+    ADD CONSTRAINT chk_order_items_kitchen_status CHECK (kitchen_status IN ('Recibido', 'En preparacion', 'Listo')),
+    ADD CONSTRAINT chk_order_items_quantity CHECK (quantity > 0) NOT VALID;
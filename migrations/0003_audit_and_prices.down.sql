@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS product_prices;
+DROP INDEX IF EXISTS idx_products_category;
+DROP INDEX IF EXISTS idx_products_archived_at;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS archived_at;
+DROP TABLE IF EXISTS audit_logs;
+DROP FUNCTION IF EXISTS audit_logs_append_only();
@@ -0,0 +1,49 @@
+-- Auditoría de escrituras, archivado de productos, categorías e historial de precios.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint      NOT NULL,
+    pattern    text        NOT NULL,
+    entity     text        NOT NULL,
+    entity_id  bigint,
+    action     text        NOT NULL,
+    before     jsonb,
+    after      jsonb,
+    created_at timestamptz NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_id ON audit_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- La auditoría es solo de inserción
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_audit_logs_append_only ON audit_logs;
+CREATE TRIGGER trg_audit_logs_append_only
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS archived_at timestamptz,
+    ADD COLUMN IF NOT EXISTS category    text NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_products_archived_at ON products (archived_at);
+CREATE INDEX IF NOT EXISTS idx_products_category ON products (category);
+
+CREATE TABLE IF NOT EXISTS product_prices (
+    id             bigserial PRIMARY KEY,
+    product_id     bigint      NOT NULL,
+    price          bigint      NOT NULL,
+    effective_from timestamptz NOT NULL,
+    effective_to   timestamptz,
+    changed_by     bigint      NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_product_prices_product_id ON product_prices (product_id);
+
+-- Cada producto existente parte con su precio actual como vigente
+INSERT INTO product_prices (product_id, price, effective_from, changed_by)
+SELECT p.id, p.price, now(), 0
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM product_prices pp WHERE pp.product_id = p.id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS z_reports;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS shifts;
+ALTER TABLE orders DROP COLUMN IF EXISTS covers;
@@ -0,0 +1,47 @@
+-- Comensales por comanda, turnos de caja, pagos e informes Z.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS covers bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS shifts (
+    id            bigserial PRIMARY KEY,
+    opened_by     bigint      NOT NULL,
+    opened_at     timestamptz NOT NULL,
+    starting_cash bigint      NOT NULL,
+    closed_by     bigint,
+    closed_at     timestamptz,
+    estado        text        NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_shifts_estado ON shifts (estado);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id         bigserial PRIMARY KEY,
+    shift_id   bigint NOT NULL REFERENCES shifts (id),
+    order_id   bigint NOT NULL,
+    tender     text   NOT NULL,
+    amount     bigint NOT NULL CHECK (amount > 0),
+    tip        bigint NOT NULL DEFAULT 0 CHECK (tip >= 0),
+    user_id    bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_payments_shift_id ON payments (shift_id);
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments (order_id);
+
+CREATE TABLE IF NOT EXISTS z_reports (
+    id               bigserial PRIMARY KEY,
+    shift_id         bigint      NOT NULL,
+    generated_at     timestamptz NOT NULL,
+    generated_by     bigint      NOT NULL,
+    starting_cash    bigint      NOT NULL,
+    totals_by_tender jsonb,
+    total_sales      bigint      NOT NULL,
+    total_tips       bigint      NOT NULL,
+    expected_cash    bigint      NOT NULL,
+    payments_count   bigint      NOT NULL,
+    voids_count      bigint      NOT NULL,
+    voids_amount     bigint      NOT NULL,
+    comps_count      bigint      NOT NULL,
+    comps_amount     bigint      NOT NULL,
+    pending_orders   bigint      NOT NULL,
+    pending_amount   bigint      NOT NULL,
+    forced           boolean     NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_z_reports_shift_id ON z_reports (shift_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_products_name_trgm;
+DROP INDEX IF EXISTS idx_products_search;
+DROP FUNCTION IF EXISTS f_unaccent(text);
@@ -0,0 +1,13 @@
+-- Búsqueda de productos: texto completo en español sin acentos y similitud de trigramas.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- unaccent no es IMMUTABLE, por lo que no se puede usar en índices sin este envoltorio
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS
+    $$ SELECT public.unaccent('public.unaccent', $1) $$
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+CREATE INDEX IF NOT EXISTS idx_products_search ON products
+    USING gin (to_tsvector('spanish', f_unaccent(name || ' ' || description)));
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products
+    USING gin (f_unaccent(lower(name)) gin_trgm_ops);
@@ -0,0 +1,10 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS guest_allergies;
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS dietary_tags,
+    DROP COLUMN IF EXISTS allergens;
+ALTER TABLE products
+    DROP COLUMN IF EXISTS dietary_tags,
+    DROP COLUMN IF EXISTS allergens;
+DROP TABLE IF EXISTS product_images;
+DROP TABLE IF EXISTS category_translations;
+DROP TABLE IF EXISTS product_translations;
@@ -0,0 +1,39 @@
+-- Traducciones, imágenes, alérgenos y etiquetas de dieta del menú, y alergias de los comensales.
+CREATE TABLE IF NOT EXISTS product_translations (
+    id          bigserial PRIMARY KEY,
+    product_id  bigint NOT NULL REFERENCES products (id),
+    locale      text   NOT NULL,
+    name        text   NOT NULL,
+    description text   NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_locale ON product_translations (product_id, locale);
+
+CREATE TABLE IF NOT EXISTS category_translations (
+    id       bigserial PRIMARY KEY,
+    category text NOT NULL,
+    locale   text NOT NULL,
+    name     text NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_locale ON category_translations (category, locale);
+
+CREATE TABLE IF NOT EXISTS product_images (
+    id            bigserial PRIMARY KEY,
+    product_id    bigint NOT NULL REFERENCES products (id),
+    url           text   NOT NULL,
+    thumbnail_url text   NOT NULL,
+    content_type  text   NOT NULL,
+    path          text   NOT NULL,
+    thumb_path    text   NOT NULL,
+    created_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_product_images_product_id ON product_images (product_id);
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS allergens    jsonb NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS dietary_tags jsonb NOT NULL DEFAULT '[]';
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS allergens    text,
+    ADD COLUMN IF NOT EXISTS dietary_tags text;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS guest_allergies text;
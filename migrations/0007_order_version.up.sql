@@ -0,0 +1,2 @@
+-- Versión de la comanda para control de concurrencia optimista.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 1;
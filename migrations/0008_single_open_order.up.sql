@@ -0,0 +1,30 @@
+-- Una sola comanda pendiente por mesa. Si ya hay mesas con más de una, sus items pasan a la
+-- más antigua y las demás quedan como fusionadas.
+CREATE TEMP TABLE duplicate_open_orders ON COMMIT DROP AS
+SELECT id, keep_id
+FROM (
+    SELECT id, min(id) OVER (PARTITION BY table_number) AS keep_id
+    FROM orders
+    WHERE estado = 'Pendiente'
+) ranked
+WHERE id <> keep_id;
+
+UPDATE order_items oi
+SET order_id = d.keep_id
+FROM duplicate_open_orders d
+WHERE oi.order_id = d.id;
+
+UPDATE orders
+SET estado = 'Fusionada', total_amount = 0, version = version + 1
+WHERE id IN (SELECT id FROM duplicate_open_orders);
+
+UPDATE orders o
+SET total_amount = (
+        SELECT coalesce(sum(total_price), 0)
+        FROM order_items
+        WHERE order_id = o.id AND estado = 'Activo'
+    ),
+    version = version + 1
+WHERE id IN (SELECT keep_id FROM duplicate_open_orders);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_open_table ON orders (table_number) WHERE estado = 'Pendiente';
@@ -0,0 +1,4 @@
+ALTER TABLE z_reports DROP COLUMN IF EXISTS currency, DROP COLUMN IF EXISTS total_rounding;
+ALTER TABLE payments DROP COLUMN IF EXISTS rounding;
+ALTER TABLE shifts DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,7 @@
+-- Moneda de comandas, turnos e informes Z, y redondeo de los pagos en efectivo.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'CLP';
+ALTER TABLE shifts ADD COLUMN IF NOT EXISTS currency text NOT NULL DEFAULT 'CLP';
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS rounding bigint NOT NULL DEFAULT 0;
+ALTER TABLE z_reports
+    ADD COLUMN IF NOT EXISTS total_rounding bigint NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS currency       text   NOT NULL DEFAULT 'CLP';
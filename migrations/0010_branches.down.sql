@@ -0,0 +1,31 @@
+-- Sin sucursales vuelven las restricciones de una sola sucursal; si los datos de varias las
+-- violan, la migración no se revierte en lugar de fallar a medias.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM products GROUP BY name HAVING count(*) > 1) THEN
+        RAISE EXCEPTION 'cannot revert 0010: several branches have products with the same name; rename or delete them first';
+    END IF;
+    IF EXISTS (SELECT 1 FROM orders WHERE estado = 'Pendiente' GROUP BY table_number HAVING count(*) > 1) THEN
+        RAISE EXCEPTION 'cannot revert 0010: several branches have a pending order on the same table; close them first';
+    END IF;
+END
+$$;
+
+DROP TABLE IF EXISTS product_overrides;
+
+DROP INDEX IF EXISTS idx_orders_open_table;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_open_table ON orders (table_number) WHERE estado = 'Pendiente';
+
+DROP INDEX IF EXISTS idx_products_branch_name;
+ALTER TABLE products ADD CONSTRAINT products_name_key UNIQUE (name);
+
+ALTER TABLE audit_logs     DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE z_reports      DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE payments       DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE shifts         DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE product_prices DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE order_items    DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE orders         DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE products       DROP COLUMN IF EXISTS branch_id;
+
+DROP TABLE IF EXISTS branches;
@@ -0,0 +1,56 @@
+-- Sucursales. Los datos existentes quedan en la sucursal 1; la sucursal 0 es el catálogo común,
+-- cuyos productos ven todas las sucursales.
+CREATE TABLE IF NOT EXISTS branches (
+    id         bigserial PRIMARY KEY,
+    name       text NOT NULL UNIQUE,
+    currency   text NOT NULL DEFAULT '',
+    created_at timestamptz
+);
+INSERT INTO branches (id, name, created_at) VALUES (0, 'Catálogo común', now()), (1, 'Casa matriz', now())
+ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('branches', 'id'), greatest((SELECT max(id) FROM branches), 1));
+
+ALTER TABLE products       ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE orders         ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE order_items    ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE product_prices ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE shifts         ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE payments       ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE z_reports      ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+ALTER TABLE audit_logs     ADD COLUMN IF NOT EXISTS branch_id bigint NOT NULL DEFAULT 1 REFERENCES branches (id);
+
+-- Sin valor por defecto: una inserción que no indique la sucursal falla en vez de caer en la 1
+ALTER TABLE products       ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE orders         ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE order_items    ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE product_prices ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE shifts         ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE payments       ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE z_reports      ALTER COLUMN branch_id DROP DEFAULT;
+ALTER TABLE audit_logs     ALTER COLUMN branch_id DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_products_branch_id ON products (branch_id);
+CREATE INDEX IF NOT EXISTS idx_orders_branch_id ON orders (branch_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_branch_id ON order_items (branch_id);
+CREATE INDEX IF NOT EXISTS idx_product_prices_branch_id ON product_prices (branch_id);
+CREATE INDEX IF NOT EXISTS idx_shifts_branch_id ON shifts (branch_id);
+CREATE INDEX IF NOT EXISTS idx_payments_branch_id ON payments (branch_id);
+CREATE INDEX IF NOT EXISTS idx_z_reports_branch_id ON z_reports (branch_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_branch_id ON audit_logs (branch_id);
+
+-- Los nombres de producto y las mesas se repiten entre sucursales
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_name_key;
+DROP INDEX IF EXISTS idx_products_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_branch_name ON products (branch_id, name);
+
+DROP INDEX IF EXISTS idx_orders_open_table;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_open_table ON orders (branch_id, table_number) WHERE estado = 'Pendiente';
+
+-- Precio y disponibilidad de los productos del catálogo común en cada sucursal
+CREATE TABLE IF NOT EXISTS product_overrides (
+    product_id     bigint  NOT NULL REFERENCES products (id),
+    branch_id      bigint  NOT NULL REFERENCES branches (id),
+    override_price bigint  CHECK (override_price > 0),
+    hidden         boolean NOT NULL DEFAULT false,
+    PRIMARY KEY (product_id, branch_id)
+);
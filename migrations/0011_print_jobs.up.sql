@@ -0,0 +1,23 @@
+-- Cola de impresión de los tickets de cocina.
+CREATE TABLE IF NOT EXISTS print_jobs (
+    id              bigserial PRIMARY KEY,
+    branch_id       bigint      NOT NULL REFERENCES branches (id),
+    order_id        bigint      NOT NULL,
+    table_number    bigint      NOT NULL,
+    station         text        NOT NULL,
+    item_ids        text,
+    content         bytea       NOT NULL,
+    estado          text        NOT NULL DEFAULT 'Pendiente',
+    attempts        bigint      NOT NULL DEFAULT 0,
+    last_error      text,
+    next_attempt_at timestamptz NOT NULL,
+    reprint_of      bigint REFERENCES print_jobs (id),
+    printed_at      timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_branch_id ON print_jobs (branch_id);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_order_id ON print_jobs (order_id);
+CREATE INDEX IF NOT EXISTS idx_print_jobs_estado ON print_jobs (estado);
+-- El proceso de impresión busca los pendientes que ya toca intentar
+CREATE INDEX IF NOT EXISTS idx_print_jobs_due ON print_jobs (next_attempt_at) WHERE estado = 'Pendiente';
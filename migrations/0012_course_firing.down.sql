@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_order_items_kitchen;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS fired_at,
+    DROP COLUMN IF EXISTS held,
+    DROP COLUMN IF EXISTS course;
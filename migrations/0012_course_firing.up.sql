@@ -0,0 +1,11 @@
+-- Tiempos del menú: los items se pueden retener y enviar a cocina junto con su tiempo.
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS course   bigint  NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS held     boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS fired_at timestamptz;
+
+-- Los items anteriores se enviaron a cocina al agregarlos; los que no tienen fecha, ahora
+UPDATE order_items SET fired_at = COALESCE(created_at, now()) WHERE fired_at IS NULL AND NOT held;
+
+-- La vista de cocina busca los items enviados que no están listos
+CREATE INDEX IF NOT EXISTS idx_order_items_kitchen ON order_items (fired_at) WHERE NOT held AND kitchen_status <> 'Listo';
@@ -0,0 +1,3 @@
+UPDATE print_jobs SET estado = 'Pendiente' WHERE estado = 'Enviando';
+DROP INDEX IF EXISTS idx_print_jobs_due;
+CREATE INDEX IF NOT EXISTS idx_print_jobs_due ON print_jobs (next_attempt_at) WHERE estado = 'Pendiente';
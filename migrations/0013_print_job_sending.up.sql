@@ -0,0 +1,3 @@
+-- Los trabajos Enviando cuyo plazo venció vuelven a tomarse, así que también entran al índice
+DROP INDEX IF EXISTS idx_print_jobs_due;
+CREATE INDEX IF NOT EXISTS idx_print_jobs_due ON print_jobs (next_attempt_at) WHERE estado IN ('Pendiente', 'Enviando');
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_shifts_open_branch;
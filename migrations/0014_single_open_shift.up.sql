@@ -0,0 +1,11 @@
+-- Un solo turno abierto por sucursal. Si ya hay sucursales con más de uno, queda abierto el más
+-- antiguo y los demás se cierran para revisarlos.
+UPDATE shifts s
+SET estado = 'Cerrado', closed_at = now()
+WHERE estado = 'Abierto'
+  AND EXISTS (
+      SELECT 1 FROM shifts o
+      WHERE o.branch_id = s.branch_id AND o.estado = 'Abierto' AND o.id < s.id
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open_branch ON shifts (branch_id) WHERE estado = 'Abierto';
@@ -0,0 +1,3 @@
+ALTER TABLE z_reports DROP CONSTRAINT IF EXISTS fk_z_reports_shift;
+DROP TRIGGER IF EXISTS trg_z_reports_immutable ON z_reports;
+DROP FUNCTION IF EXISTS z_reports_immutable();
@@ -0,0 +1,16 @@
+-- Los informes Z no se pueden modificar ni borrar, tampoco con SQL directo, y siempre pertenecen
+-- a un turno.
+CREATE OR REPLACE FUNCTION z_reports_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'z_reports is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_z_reports_immutable ON z_reports;
+CREATE TRIGGER trg_z_reports_immutable
+    BEFORE UPDATE OR DELETE ON z_reports
+    FOR EACH ROW EXECUTE FUNCTION z_reports_immutable();
+
+ALTER TABLE z_reports DROP CONSTRAINT IF EXISTS fk_z_reports_shift;
+ALTER TABLE z_reports
+    ADD CONSTRAINT fk_z_reports_shift FOREIGN KEY (shift_id) REFERENCES shifts (id);
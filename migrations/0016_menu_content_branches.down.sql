@@ -0,0 +1,19 @@
+-- Sin sucursal, una categoría o producto solo puede tener una traducción por idioma
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM category_translations GROUP BY category, locale HAVING count(*) > 1)
+       OR EXISTS (SELECT 1 FROM product_translations GROUP BY product_id, locale HAVING count(*) > 1) THEN
+        RAISE EXCEPTION 'cannot revert 0016: some branches translate the same category or product; delete their translations first';
+    END IF;
+END
+$$;
+
+DROP INDEX IF EXISTS idx_category_locale;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_locale ON category_translations (category, locale);
+DROP INDEX IF EXISTS idx_product_locale;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_locale ON product_translations (product_id, locale);
+
+DROP INDEX IF EXISTS idx_product_images_branch_id;
+ALTER TABLE product_images        DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE category_translations DROP COLUMN IF EXISTS branch_id;
+ALTER TABLE product_translations  DROP COLUMN IF EXISTS branch_id;
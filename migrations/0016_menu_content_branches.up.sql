@@ -0,0 +1,25 @@
+-- Traducciones e imágenes por sucursal. Las de un producto quedan en la sucursal del producto y
+-- las traducciones de categorías existentes pasan al catálogo común, que ven todas.
+ALTER TABLE product_translations  ADD COLUMN IF NOT EXISTS branch_id bigint REFERENCES branches (id);
+ALTER TABLE category_translations ADD COLUMN IF NOT EXISTS branch_id bigint REFERENCES branches (id);
+ALTER TABLE product_images        ADD COLUMN IF NOT EXISTS branch_id bigint REFERENCES branches (id);
+
+UPDATE product_translations t SET branch_id = p.branch_id
+FROM products p
+WHERE p.id = t.product_id AND t.branch_id IS NULL;
+UPDATE product_images i SET branch_id = p.branch_id
+FROM products p
+WHERE p.id = i.product_id AND i.branch_id IS NULL;
+UPDATE category_translations SET branch_id = 0 WHERE branch_id IS NULL;
+
+ALTER TABLE product_translations  ALTER COLUMN branch_id SET NOT NULL;
+ALTER TABLE category_translations ALTER COLUMN branch_id SET NOT NULL;
+ALTER TABLE product_images        ALTER COLUMN branch_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_images_branch_id ON product_images (branch_id);
+
+-- Cada sucursal tiene su propia traducción de una categoría o producto
+DROP INDEX IF EXISTS idx_product_locale;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_locale ON product_translations (branch_id, product_id, locale);
+DROP INDEX IF EXISTS idx_category_locale;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_category_locale ON category_translations (branch_id, category, locale);
@@ -0,0 +1,147 @@
+package migrations_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/migrations"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// freshSchema abre la base de TEST_DB_URL en un esquema nuevo que se borra al terminar, porque
+// revertir migraciones sobre un esquema compartido borraría datos. Las extensiones quedan en public.
+func freshSchema(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set; this test needs a Postgres database")
+	}
+
+	silent := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	admin, err := gorm.Open(postgres.Open(dsn), silent)
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	schema := fmt.Sprintf("migrations_%d", time.Now().UnixNano())
+	for _, stmt := range []string{
+		"CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public",
+		"CREATE EXTENSION IF NOT EXISTS pg_trgm WITH SCHEMA public",
+		"CREATE SCHEMA " + schema,
+	} {
+		if err := admin.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	conn, err := gorm.Open(postgres.Open(withSearchPath(dsn, schema+",public")), silent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// withSearchPath agrega search_path a la cadena de conexión, sea una URL o pares clave=valor.
+func withSearchPath(dsn string, path string) string {
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		return dsn + separator + "search_path=" + strings.ReplaceAll(path, ",", "%2C")
+	}
+	return dsn + " search_path=" + path
+}
+
+// revertBranches revierte las migraciones desde la 0010 (la de sucursales) hasta la última aplicada.
+func revertBranches(t *testing.T, conn *gorm.DB) error {
+	t.Helper()
+	statuses, err := migrations.GetStatus(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := 0
+	for _, status := range statuses {
+		if status.Version >= 10 && status.AppliedAt != nil {
+			steps++
+		}
+	}
+	_, err = migrations.Down(conn, steps)
+	return err
+}
+
+func count(t *testing.T, conn *gorm.DB, table string) int64 {
+	t.Helper()
+	var n int64
+	if err := conn.Table(table).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// Con datos de dos sucursales que no chocan, bajar y volver a subir conserva las filas; si dos
+// sucursales comparten un nombre de producto o una mesa abierta, la 0010 se niega a revertirse.
+func TestBranchesRoundTripPostgres(t *testing.T) {
+	conn := freshSchema(t)
+	if _, err := migrations.Up(conn, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stmt := range []string{
+		`INSERT INTO branches (id, name) VALUES (2, 'Sucursal Centro')`,
+		`INSERT INTO products (name, description, price, branch_id) VALUES ('Lomo', 'Lomo a lo pobre', 9000, 1), ('Chorrillana', 'Para compartir', 12000, 2)`,
+		`INSERT INTO orders (table_number, user_id, order_date, total_amount, estado, branch_id) VALUES (1, 1, now(), 0, 'Pendiente', 1), (2, 1, now(), 0, 'Pendiente', 2)`,
+	} {
+		if err := conn.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := revertBranches(t, conn); err != nil {
+		t.Fatalf("reverting without collisions: %v", err)
+	}
+	if _, err := migrations.Up(conn, 0); err != nil {
+		t.Fatalf("applying again: %v", err)
+	}
+	if n := count(t, conn, "products"); n != 2 {
+		t.Fatalf("products after the round trip = %d, want 2", n)
+	}
+	if n := count(t, conn, "orders"); n != 2 {
+		t.Fatalf("orders after the round trip = %d, want 2", n)
+	}
+
+	for _, stmt := range []string{
+		`INSERT INTO branches (id, name) VALUES (2, 'Sucursal Centro') ON CONFLICT DO NOTHING`,
+		`INSERT INTO products (name, description, price, branch_id) VALUES ('Lomo', 'Lomo a lo pobre', 9500, 2)`,
+		`INSERT INTO orders (table_number, user_id, order_date, total_amount, estado, branch_id) VALUES (1, 1, now(), 0, 'Pendiente', 2)`,
+	} {
+		if err := conn.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := revertBranches(t, conn)
+	if err == nil || !strings.Contains(err.Error(), "cannot revert 0010") {
+		t.Fatalf("reverting with collisions: got %v, want a cannot revert 0010 error", err)
+	}
+	statuses, err := migrations.GetStatus(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if status.Version == 10 && status.AppliedAt == nil {
+			t.Fatal("migration 0010 was reverted despite the collisions")
+		}
+	}
+	if n := count(t, conn, "products"); n != 3 {
+		t.Fatalf("products after the failed revert = %d, want 3", n)
+	}
+	if _, err := migrations.Up(conn, 0); err != nil {
+		t.Fatalf("applying the migrations reverted before 0010: %v", err)
+	}
+}
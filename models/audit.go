@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Registro de auditoría de una escritura. La tabla es solo de inserción: nunca se actualiza ni se borra.
+type AuditLog struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`                 // Identificador del registro
+	BranchID  uint            `gorm:"index;not null" json:"branch_id"`     // Sucursal en que se hizo el cambio
+	UserID    uint            `gorm:"index;not null" json:"user_id"`       // Usuario que realizó el cambio (0 si no se autenticó)
+	Pattern   string          `gorm:"not null" json:"pattern"`             // Patrón RPC que originó el cambio
+	Entity    string          `gorm:"index:idx_audit_entity;not null" json:"entity"` // Tipo de entidad modificada
+	EntityID  uint            `gorm:"index:idx_audit_entity" json:"entity_id"`       // Identificador de la entidad
+	Action    string          `gorm:"not null" json:"action"`              // create, update o delete
+	Before    json.RawMessage `gorm:"type:jsonb" json:"before"`            // Estado previo de la entidad
+	After     json.RawMessage `gorm:"type:jsonb" json:"after"`             // Estado posterior de la entidad
+	CreatedAt time.Time       `gorm:"index;not null" json:"created_at"`    // Fecha del cambio
+}
+
+// Datos de la petición que se guardan en la auditoría de cada escritura.
+type AuditInfo struct {
+	UserID   uint   // Usuario obtenido del token de autorización
+	Role     string // Rol del usuario según el token (mesero, encargado, admin...)
+	BranchID uint   // Sucursal de la petición; todas las lecturas y escrituras se limitan a ella
+	Pattern  string // Patrón RPC recibido
+}
+
+// Roles que pueden aprobar anulaciones, cortesías y cambios forzados de items.
+var ManagerRoles = map[string]bool{
+	"encargado": true,
+	"admin":     true,
+}
+
+// Encargado que aprueba una operación, con el rol de su token verificado.
+type Approval struct {
+	UserID uint   // Encargado que aprueba
+	Role   string // Rol del encargado según su token
+}
+
+// Valid indica si quien aprueba está identificado y tiene un rol de encargado.
+func (a Approval) Valid() bool {
+	return a.UserID != 0 && ManagerRoles[a.Role]
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+)
+
+// Sucursal del restaurante. Cada sucursal ve solo sus comandas, turnos y productos, más los
+// productos del catálogo común (BranchID 0).
+type Branch struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`                // Identificador de la sucursal
+	Name      string    `gorm:"not null;unique" json:"name"`         // Nombre de la sucursal
+	Currency  string    `gorm:"not null;default:''" json:"currency"` // Moneda en que cobra; vacía usa la configurada en CURRENCY
+	CreatedAt time.Time `json:"created_at"`                          // Fecha de creación
+}
+
+// Precio o disponibilidad propios de una sucursal para un producto del catálogo común.
+type ProductOverride struct {
+	ProductID uint          `gorm:"primaryKey" json:"product_id"`         // Producto del catálogo común
+	BranchID  uint          `gorm:"primaryKey" json:"branch_id"`          // Sucursal que lo cambia
+	Price     *money.Amount `gorm:"column:override_price" json:"price"`   // Precio en la sucursal; nil usa el del catálogo
+	Hidden    bool          `gorm:"not null;default:false" json:"hidden"` // Si la sucursal no lo ofrece en su menú
+}
+
+// Apply devuelve el producto con el precio de la sucursal.
+func (o ProductOverride) Apply(product Product) Product {
+	if o.Price != nil {
+		product.Price = *o.Price
+	}
+	return product
+}
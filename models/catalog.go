@@ -0,0 +1,35 @@
+package models
+
+import "github.com/FelipeGeraldoblufus/Comandas-ms/money"
+
+// Fila del catálogo de productos usada en la importación y exportación.
+type CatalogRow struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Price       money.Amount `json:"price"`
+	Category    string `json:"category"`
+}
+
+// Problema encontrado en una fila del archivo importado.
+type ImportIssue struct {
+	Row     int    `json:"row"`     // Número de fila en el archivo (1 = primera fila de datos)
+	Name    string `json:"name"`    // Nombre del producto de la fila
+	Message string `json:"message"` // Descripción del problema
+}
+
+// Resultado de una importación del catálogo.
+type ImportResult struct {
+	DryRun    bool          `json:"dry_run"`   // Si solo se simuló la importación
+	Applied   bool          `json:"applied"`   // Si los cambios se guardaron
+	Created   []string      `json:"created"`   // Productos que se crean
+	Updated   []string      `json:"updated"`   // Productos que se actualizan
+	Unchanged []string      `json:"unchanged"` // Productos sin cambios
+	Conflicts []ImportIssue `json:"conflicts"` // Filas que chocan con el catálogo existente
+	Errors    []ImportIssue `json:"errors"`    // Filas inválidas
+}
+
+// Producto encontrado por la búsqueda, con su relevancia.
+type ProductSearchResult struct {
+	Product
+	Rank float64 `json:"rank"` // Relevancia del resultado (mayor es mejor)
+}
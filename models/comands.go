@@ -1,35 +1,106 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"gorm.io/gorm"
+)
 
 // Representa los productos disponibles en el restaurante.
 type Product struct {
 	ID          uint    `gorm:"primaryKey" json:"id"`          // Identificador único del producto
-	Name        string  `gorm:"not null;unique" json:"name"`   // Nombre del producto
+	BranchID    uint    `gorm:"index;not null" json:"branch_id"` // Sucursal dueña del producto; 0 es el catálogo común
+	Name        string  `gorm:"not null" json:"name"`          // Nombre del producto, único por sucursal
 	Description string  `gorm:"not null" json:"description"`   // Descripción del producto
-	Price       int `gorm:"not null" json:"price"`         // Precio del producto
+	Price       money.Amount `gorm:"not null" json:"price"` // Precio del producto, en unidades menores de la moneda
+	ArchivedAt  *time.Time `gorm:"index" json:"archived_at,omitempty"` // Fecha de archivado; los archivados no aparecen en el menú
+	Category    string     `gorm:"index;not null;default:''" json:"category"` // Categoría del menú (entradas, fondos, bebidas...)
+	Images      []ProductImage `gorm:"foreignKey:ProductID" json:"images,omitempty"` // Fotos del producto
+	Allergens   []string   `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"allergens"`    // Alérgenos que contiene
+	DietaryTags []string   `gorm:"type:jsonb;serializer:json;not null;default:'[]'" json:"dietary_tags"` // Vegano, vegetariano, picante...
 }
 
 // Representa un item dentro de una comanda (similar a un CartItem).
 type OrderItem struct {
 	ID         uint    `gorm:"primaryKey" json:"id"`          // Identificador único del item
+	BranchID   uint    `gorm:"index;not null" json:"branch_id"` // Sucursal de la comanda
 	UserID     uint    `gorm:"not null" json:"user_id"`       // Usuario asociado (mientras no está confirmado)
 	OrderID    *uint   `gorm:"index" json:"order_id"`         // Referencia a la comanda (si está confirmado)
 	ProductID  uint    `gorm:"not null" json:"product_id"`    // Producto asociado
 	Product    Product `gorm:"foreignKey:ProductID" json:"product"` // Detalles del producto
 	Quantity   int     `gorm:"not null" json:"quantity"`      // Cantidad solicitada
-	TotalPrice int `gorm:"not null" json:"total_price"`   // Total del producto (Price * Quantity)
+	TotalPrice money.Amount `gorm:"not null" json:"total_price"` // Total del producto (Price * Quantity)
 	TableNumber  int        `gorm:"not null" json:"table_number"`      // Número de mesa
+	Estado       string     `gorm:"not null;default:Activo" json:"estado"` // Activo, Anulado o Cortesia
+	ReasonCode   string     `json:"reason_code,omitempty"`                 // Motivo de la anulación o cortesía
+	VoidedBy     *uint      `json:"voided_by,omitempty"`                   // Usuario que anuló o regaló el item
+	ApprovedBy   *uint      `json:"approved_by,omitempty"`                 // Encargado que aprobó la operación
+	WasPrepared  bool       `gorm:"not null;default:false" json:"was_prepared"` // Si ya se había preparado en cocina
+	VoidedAt     *time.Time `json:"voided_at,omitempty"`                   // Fecha de la anulación o cortesía
+	Notes         string     `json:"notes"`                                          // Indicaciones para cocina
+	Modifiers     []string   `gorm:"serializer:json" json:"modifiers"`               // Modificadores (sin cebolla, extra queso...)
+	KitchenStatus string     `gorm:"not null;default:Recibido" json:"kitchen_status"` // Recibido, En preparacion o Listo
+	Course        int        `gorm:"not null;default:1" json:"course"`               // Tiempo del menú en que se sirve (1 entradas, 2 fondos...)
+	Held          bool       `gorm:"not null;default:false" json:"held"`             // Retenido: no va a cocina hasta disparar su tiempo
+	FiredAt       *time.Time `json:"fired_at,omitempty"`                             // Fecha en que se envió a cocina
+	CreatedAt     time.Time  `json:"created_at"`                                     // Fecha en que se agregó el item
+	UpdatedAt     time.Time  `json:"updated_at"`                                     // Última modificación del item
+	ProductName   string     `json:"product_name"`                                   // Nombre del producto al momento de agregarlo
+	UnitPrice     money.Amount `gorm:"not null;default:0" json:"unit_price"`           // Precio unitario al momento de agregarlo
+	Allergens     []string   `gorm:"serializer:json" json:"allergens"`               // Alérgenos del producto, copiados para cocina
+	DietaryTags   []string   `gorm:"serializer:json" json:"dietary_tags"`            // Etiquetas de dieta, copiadas para cocina
+	Warnings      []string   `gorm:"-" json:"warnings,omitempty"`                    // Avisos al agregar el item (alergias de la mesa)
+}
+
+// AfterFind reemplaza el nombre y precio del producto precargado por los guardados al agregar el item,
+// para que una comanda antigua muestre el precio que se cobró y no el actual.
+func (item *OrderItem) AfterFind(tx *gorm.DB) error {
+	if item.ProductName != "" {
+		item.Product.Name = item.ProductName
+		item.Product.Price = item.UnitPrice
+	}
+	return nil
+}
+
+// Estados del item en cocina. Una vez en preparación solo un encargado puede modificarlo.
+const (
+	KitchenRecibido      = "Recibido"
+	KitchenEnPreparacion = "En preparacion"
+	KitchenListo         = "Listo"
+)
+
+// Estados posibles de un OrderItem. Solo los items activos suman al TotalAmount de la comanda.
+const (
+	OrderItemActivo   = "Activo"
+	OrderItemAnulado  = "Anulado"
+	OrderItemCortesia = "Cortesia"
+)
+
+// Motivos aceptados para anular un item o darlo como cortesía.
+var VoidReasonCodes = map[string]string{
+	"ERROR_MESERO":      "Error al ingresar el pedido",
+	"CLIENTE_CAMBIO":    "El cliente cambió de opinión",
+	"PRODUCTO_MALO":     "Producto en mal estado o mal preparado",
+	"DEMORA":            "Demora excesiva en la entrega",
+	"SIN_STOCK":         "Producto sin stock",
+	"CORTESIA_CASA":     "Cortesía de la casa",
+	"CLIENTE_FRECUENTE": "Atención a cliente frecuente",
 }
 
 // Representa una comanda en el restaurante.
 type Order struct {
 	ID           uint       `gorm:"primaryKey" json:"id"`              // Identificador único de la comanda
-	TableNumber  int        `gorm:"not null" json:"table_number"`      // Número de mesa
+	BranchID     uint       `gorm:"index;not null" json:"branch_id"`   // Sucursal de la comanda
+	TableNumber  int        `gorm:"not null" json:"table_number"`      // Número de mesa, único dentro de la sucursal
 	UserID       uint       `gorm:"not null" json:"user_id"`           // Usuario asignado a la comanda
 	Items        []OrderItem `gorm:"foreignKey:OrderID" json:"items"`         // Productos incluidos en la comanda
 	OrderDate    time.Time  `gorm:"not null" json:"order_date"`        // Fecha de creación del pedido
-	TotalAmount  int    `gorm:"not null" json:"total_amount"`      // Total de la comanda
+	TotalAmount  money.Amount `gorm:"not null" json:"total_amount"` // Total de la comanda
 	Estado string `gorm:"not null" json:"estado"`
+	Covers       int        `gorm:"not null;default:0" json:"covers"`  // Cantidad de comensales
+	GuestAllergies []string `gorm:"serializer:json" json:"guest_allergies"` // Alergias declaradas por los comensales
+	Version        int      `gorm:"not null;default:1" json:"version"`      // Aumenta en cada cambio; evita pisar cambios concurrentes
+	Currency       string   `gorm:"not null;default:CLP" json:"currency"`   // Moneda en que se cobra la comanda
 }
 
@@ -0,0 +1,22 @@
+package models
+
+// Alérgenos que se pueden declarar en un producto o como alergia de los comensales.
+var Allergens = map[string]string{
+	"gluten":       "Gluten",
+	"lactosa":      "Lactosa",
+	"frutos_secos": "Frutos secos",
+	"mani":         "Maní",
+	"mariscos":     "Mariscos",
+	"pescado":      "Pescado",
+	"huevo":        "Huevo",
+	"soya":         "Soya",
+	"sesamo":       "Sésamo",
+}
+
+// Etiquetas de dieta de un producto.
+var DietaryTags = map[string]string{
+	"vegano":      "Vegano",
+	"vegetariano": "Vegetariano",
+	"sin_gluten":  "Sin gluten",
+	"picante":     "Picante",
+}
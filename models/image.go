@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Imagen de un producto para el menú. Los archivos se guardan en el almacenamiento configurado.
+// Las imágenes de los productos del catálogo común las ven todas las sucursales.
+type ProductImage struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`               // Identificador de la imagen
+	BranchID     uint      `gorm:"index;not null" json:"branch_id"`   // Sucursal del producto; 0 es el catálogo común
+	ProductID    uint      `gorm:"index;not null" json:"product_id"`  // Producto al que pertenece
+	URL          string    `gorm:"not null" json:"url"`               // URL de la imagen original
+	ThumbnailURL string    `gorm:"not null" json:"thumbnail_url"`     // URL de la miniatura
+	ContentType  string    `gorm:"not null" json:"content_type"`      // Tipo de la imagen original
+	Path         string    `gorm:"not null" json:"-"`                 // Nombre del archivo en el almacenamiento
+	ThumbPath    string    `gorm:"not null" json:"-"`                 // Nombre de la miniatura en el almacenamiento
+	CreatedAt    time.Time `json:"created_at"`                        // Fecha de subida
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Parámetros de paginación, filtros y orden para los patrones que listan datos.
+// Los filtros vacíos se ignoran.
+type ListParams struct {
+	Limit       int        `json:"limit"`        // Cantidad de resultados por página (por defecto 50, máximo 500)
+	Cursor      string     `json:"cursor"`       // Cursor devuelto por la página anterior
+	SortBy      string     `json:"sort_by"`      // Campo por el que se ordena
+	SortDir     string     `json:"sort_dir"`     // asc o desc
+	Status      string     `json:"status"`       // Estado de la comanda o del item
+	TableNumber *int       `json:"table_number"` // Número de mesa
+	UserID      *uint      `json:"user_id"`      // Usuario
+	ProductID   *uint      `json:"product_id"`   // Producto
+	Category    string     `json:"category"`     // Categoría del producto
+	From        *time.Time `json:"from"`         // Fecha desde (RFC3339)
+	To          *time.Time `json:"to"`           // Fecha hasta, excluida (RFC3339)
+
+	IncludeArchived bool   `json:"include_archived"` // Incluir productos archivados
+	Locale          string `json:"locale"`           // Idioma de los textos del menú (es por defecto)
+
+	ExcludeAllergens []string `json:"exclude_allergens"` // Excluir productos con estos alérgenos
+	DietaryTags      []string `json:"dietary_tags"`      // Solo productos con todas estas etiquetas
+}
+
+// Página de resultados de un listado.
+type Page[T any] struct {
+	Items      []T    `json:"items"`       // Resultados de la página
+	Total      int64  `json:"total"`       // Total de resultados con los filtros aplicados
+	NextCursor string `json:"next_cursor"` // Cursor para la página siguiente, vacío si no hay más
+}
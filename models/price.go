@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+)
+
+// Historial de precios de un producto. El precio vigente es el que tiene EffectiveTo en nil.
+type ProductPrice struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`                  // Identificador del registro
+	BranchID      uint       `gorm:"index;not null" json:"branch_id"`      // Sucursal del producto
+	ProductID     uint       `gorm:"index;not null" json:"product_id"`     // Producto al que pertenece el precio
+	Price         money.Amount `gorm:"not null" json:"price"`                // Precio vigente en el periodo
+	EffectiveFrom time.Time  `gorm:"not null" json:"effective_from"`       // Inicio de vigencia
+	EffectiveTo   *time.Time `json:"effective_to"`                         // Fin de vigencia (nil si es el precio actual)
+	ChangedBy     uint       `gorm:"not null" json:"changed_by"`           // Usuario que fijó el precio
+}
+
+// Moneda del restaurante, para que los clientes sepan cómo mostrar los montos en unidades menores.
+type CurrencySettings struct {
+	money.Currency
+	Rounding money.RoundingMode `json:"rounding"` // Redondeo de los cálculos que no dan un monto exacto
+}
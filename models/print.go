@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Ticket de cocina en la cola de impresión. Se arma al enviar los items a la estación y un
+// proceso aparte lo manda a la impresora, reintentando si no responde.
+type PrintJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`                           // Identificador del trabajo
+	BranchID      uint       `gorm:"index;not null" json:"branch_id"`                // Sucursal de la comanda
+	OrderID       uint       `gorm:"index;not null" json:"order_id"`                 // Comanda de los items
+	TableNumber   int        `gorm:"not null" json:"table_number"`                   // Mesa de la comanda
+	Station       string     `gorm:"not null" json:"station"`                        // Estación que imprime el ticket (cocina, barra...)
+	ItemIDs       []uint     `gorm:"serializer:json" json:"item_ids"`                // Items incluidos en el ticket
+	Content       []byte     `gorm:"not null" json:"-"`                              // Ticket en ESC/POS
+	Estado        string     `gorm:"index;not null;default:Pendiente" json:"estado"` // Pendiente, Enviando, Impreso o Fallido
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`             // Intentos de envío a la impresora
+	LastError     string     `json:"last_error,omitempty"`                           // Error del último intento fallido
+	NextAttemptAt time.Time  `gorm:"not null" json:"next_attempt_at"`                // Cuándo se vuelve a intentar
+	ReprintOf     *uint      `json:"reprint_of,omitempty"`                           // Trabajo original si es una reimpresión
+	PrintedAt     *time.Time `json:"printed_at,omitempty"`                           // Fecha en que se imprimió
+	CreatedAt     time.Time  `json:"created_at"`                                     // Fecha en que se encoló
+	UpdatedAt     time.Time  `json:"updated_at"`                                     // Último intento o cambio
+}
+
+// Estados de un trabajo de impresión. Un trabajo queda Enviando mientras un proceso lo manda a la
+// impresora y Fallido al agotar los reintentos.
+const (
+	PrintJobPendiente = "Pendiente"
+	PrintJobEnviando  = "Enviando"
+	PrintJobImpreso   = "Impreso"
+	PrintJobFallido   = "Fallido"
+)
@@ -0,0 +1,11 @@
+package models
+
+// Recibo o pre-cuenta de una comanda, listo para imprimir.
+type Receipt struct {
+	OrderID     uint   `json:"order_id"`           // Comanda del recibo
+	Format      string `json:"format"`             // text, escpos o pdf
+	Width       string `json:"width"`              // Ancho del papel: 58mm u 80mm
+	ContentType string `json:"content_type"`       // Tipo de contenido del formato
+	Encoding    string `json:"encoding,omitempty"` // base64 en los formatos binarios
+	Content     string `json:"content"`            // Recibo generado
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+)
+
+// Ventas agrupadas en un intervalo de tiempo (hora, día o semana).
+type SalesBucket struct {
+	Bucket        time.Time `json:"bucket"`         // Inicio del intervalo
+	Orders        int       `json:"orders"`         // Comandas en el intervalo
+	Covers        int       `json:"covers"`         // Comensales atendidos
+	Revenue       money.Amount `json:"revenue"`        // Total vendido
+	AverageTicket money.Amount `json:"average_ticket"` // Venta promedio por comanda
+}
+
+// Unidades y venta de un producto o categoría en un periodo.
+type ProductSales struct {
+	ProductID uint   `json:"product_id,omitempty"` // Producto (vacío si se agrupa por categoría)
+	Name      string `json:"name,omitempty"`       // Nombre del producto
+	Category  string `json:"category"`             // Categoría del producto
+	Units     int    `json:"units"`                // Unidades vendidas
+	Revenue   money.Amount `json:"revenue"`              // Total vendido
+}
@@ -8,4 +8,5 @@ type Response struct {
 
 type Headers struct {
 	Authorization string `json:"Authorization"`
+	Branch        *uint  `json:"Branch,omitempty"` // Sucursal de la petición, entre las que permite el token
 }
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"gorm.io/gorm"
+)
+
+// Turno de caja. Solo puede haber un turno abierto a la vez en cada sucursal.
+type Shift struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`            // Identificador del turno
+	BranchID     uint       `gorm:"index;not null" json:"branch_id"` // Sucursal de la caja
+	OpenedBy     uint       `gorm:"not null" json:"opened_by"`      // Usuario que abrió la caja
+	OpenedAt     time.Time  `gorm:"not null" json:"opened_at"`      // Apertura del turno
+	StartingCash money.Amount `gorm:"not null" json:"starting_cash"` // Efectivo inicial en caja
+	ClosedBy     *uint      `json:"closed_by,omitempty"`            // Usuario que cerró la caja
+	ClosedAt     *time.Time `json:"closed_at,omitempty"`            // Cierre del turno
+	Estado       string     `gorm:"index;not null" json:"estado"`   // Abierto o Cerrado
+	Payments     []Payment  `gorm:"foreignKey:ShiftID" json:"payments,omitempty"` // Pagos registrados en el turno
+	Currency     string     `gorm:"not null;default:CLP" json:"currency"` // Moneda de la caja
+}
+
+const (
+	ShiftAbierto = "Abierto"
+	ShiftCerrado = "Cerrado"
+)
+
+// Pago de una comanda registrado dentro de un turno.
+type Payment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`          // Identificador del pago
+	BranchID  uint      `gorm:"index;not null" json:"branch_id"` // Sucursal del pago
+	ShiftID   uint      `gorm:"index;not null" json:"shift_id"` // Turno en que se registró
+	OrderID   uint      `gorm:"index;not null" json:"order_id"` // Comanda pagada
+	Tender    string    `gorm:"not null" json:"tender"`        // Medio de pago
+	Amount    money.Amount `gorm:"not null" json:"amount"`          // Monto aplicado a la comanda
+	Tip       money.Amount `gorm:"not null;default:0" json:"tip"`   // Propina
+	Rounding  money.Amount `gorm:"not null;default:0" json:"rounding"` // Diferencia perdonada al redondear un pago en efectivo
+	UserID    uint      `gorm:"not null" json:"user_id"`       // Usuario que cobró
+	CreatedAt time.Time `json:"created_at"`                    // Fecha del pago
+}
+
+// Medios de pago aceptados.
+var Tenders = map[string]bool{
+	"Efectivo":      true,
+	"Debito":        true,
+	"Credito":       true,
+	"Transferencia": true,
+}
+
+// Informe Z del cierre de un turno. Se genera una sola vez y no se puede modificar. Los
+// descuentos del turno son las cortesías (CompsCount y CompsAmount), igual que en la boleta.
+type ZReport struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`               // Identificador del informe
+	BranchID       uint            `gorm:"index;not null" json:"branch_id"`    // Sucursal del turno
+	ShiftID        uint            `gorm:"uniqueIndex;not null" json:"shift_id"` // Turno cerrado
+	GeneratedAt    time.Time       `gorm:"not null" json:"generated_at"`      // Fecha de generación
+	GeneratedBy    uint            `gorm:"not null" json:"generated_by"`      // Usuario que cerró el turno
+	StartingCash   money.Amount    `gorm:"not null" json:"starting_cash"`     // Efectivo inicial
+	TotalsByTender json.RawMessage `gorm:"type:jsonb" json:"totals_by_tender"` // Monto cobrado por medio de pago
+	TotalSales     money.Amount    `gorm:"not null" json:"total_sales"`       // Total cobrado (sin propinas)
+	TotalTips      money.Amount    `gorm:"not null" json:"total_tips"`        // Total de propinas
+	ExpectedCash   money.Amount    `gorm:"not null" json:"expected_cash"`     // Efectivo que debería haber en caja
+	PaymentsCount  int             `gorm:"not null" json:"payments_count"`    // Cantidad de pagos
+	VoidsCount     int             `gorm:"not null" json:"voids_count"`       // Items anulados en el turno
+	VoidsAmount    money.Amount    `gorm:"not null" json:"voids_amount"`      // Monto anulado en el turno
+	CompsCount     int             `gorm:"not null" json:"comps_count"`       // Items dados como cortesía (descuentos)
+	CompsAmount    money.Amount    `gorm:"not null" json:"comps_amount"`      // Monto descontado en cortesías
+	PendingOrders  int             `gorm:"not null" json:"pending_orders"`    // Comandas que seguían pendientes al cerrar
+	PendingAmount  money.Amount    `gorm:"not null" json:"pending_amount"`    // Monto de las comandas pendientes
+	Forced         bool            `gorm:"not null" json:"forced"`            // Si se cerró con comandas pendientes
+	TotalRounding  money.Amount    `gorm:"not null;default:0" json:"total_rounding"` // Diferencias perdonadas al redondear pagos en efectivo
+	Currency       string          `gorm:"not null;default:CLP" json:"currency"` // Moneda de los montos del informe
+}
+
+// BeforeUpdate impide modificar un informe Z ya generado.
+func (r *ZReport) BeforeUpdate(tx *gorm.DB) error {
+	return errors.New("z report is immutable")
+}
+
+// BeforeDelete impide borrar un informe Z ya generado.
+func (r *ZReport) BeforeDelete(tx *gorm.DB) error {
+	return errors.New("z report is immutable")
+}
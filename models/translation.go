@@ -0,0 +1,32 @@
+package models
+
+// Idioma por defecto del menú. Los textos de Product están en este idioma.
+const DefaultLocale = "es"
+
+// Idiomas en los que se puede traducir el menú.
+var SupportedLocales = map[string]bool{
+	"es": true,
+	"en": true,
+	"pt": true,
+}
+
+// Traducción del nombre y descripción de un producto. Es de la sucursal dueña del producto; las
+// del catálogo común (BranchID 0) las ven todas las sucursales.
+type ProductTranslation struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`                                      // Identificador de la traducción
+	BranchID    uint   `gorm:"not null;uniqueIndex:idx_product_locale" json:"branch_id"`  // Sucursal de la traducción
+	ProductID   uint   `gorm:"not null;uniqueIndex:idx_product_locale" json:"product_id"` // Producto traducido
+	Locale      string `gorm:"not null;uniqueIndex:idx_product_locale" json:"locale"`     // Idioma (en, pt...)
+	Name        string `gorm:"not null" json:"name"`                                      // Nombre traducido
+	Description string `gorm:"not null" json:"description"`                               // Descripción traducida
+}
+
+// Traducción del nombre de una categoría del menú. Cada sucursal puede traducir sus categorías; la
+// del catálogo común (BranchID 0) vale para las sucursales que no tienen la suya.
+type CategoryTranslation struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`                                      // Identificador de la traducción
+	BranchID uint   `gorm:"not null;uniqueIndex:idx_category_locale" json:"branch_id"` // Sucursal de la traducción
+	Category string `gorm:"not null;uniqueIndex:idx_category_locale" json:"category"` // Categoría en español
+	Locale   string `gorm:"not null;uniqueIndex:idx_category_locale" json:"locale"`   // Idioma (en, pt...)
+	Name     string `gorm:"not null" json:"name"`                                     // Nombre traducido
+}
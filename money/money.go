@@ -0,0 +1,264 @@
+// Package money representa montos en unidades menores de su moneda (pesos para CLP, centavos para
+// USD) y aplica las reglas de redondeo y de formato de cada moneda.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Amount es un monto en unidades menores de la moneda. En JSON y en la base de datos es un entero.
+type Amount int64
+
+// Mul devuelve el monto multiplicado por una cantidad.
+func (a Amount) Mul(quantity int) Amount {
+	return a * Amount(quantity)
+}
+
+// Div divide el monto en n partes iguales, redondeando el resultado con mode.
+func (a Amount) Div(n int, mode RoundingMode) Amount {
+	if n == 0 {
+		return 0
+	}
+	return Amount(divRound(int64(a), int64(n), mode))
+}
+
+// Percent devuelve el porcentaje del monto expresado en puntos básicos (1000 = 10%), redondeado con mode.
+func (a Amount) Percent(basisPoints int, mode RoundingMode) Amount {
+	return Amount(divRound(int64(a)*int64(basisPoints), 10000, mode))
+}
+
+// Money es un monto junto con el código ISO 4217 de su moneda.
+type Money struct {
+	Amount   Amount `json:"amount"`   // Monto en unidades menores
+	Currency string `json:"currency"` // Código ISO 4217
+}
+
+// New crea un Money en la moneda dada.
+func New(amount Amount, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Add suma dos montos de la misma moneda.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Currency != other.Currency {
+		return Money{}, fmt.Errorf("cannot add %s to %s", other.Currency, m.Currency)
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+// String formatea el monto con el símbolo de su moneda, o con el código si la moneda no es conocida.
+func (m Money) String() string {
+	currency, err := Lookup(m.Currency)
+	if err != nil {
+		return fmt.Sprintf("%s %d", m.Currency, m.Amount)
+	}
+	return currency.Format(m.Amount)
+}
+
+// Currency describe cómo se escriben y redondean los montos de una moneda.
+type Currency struct {
+	Code          string       `json:"code"`           // Código ISO 4217
+	Exponent      int          `json:"exponent"`       // Decimales de la moneda: 0 para CLP, 2 para USD
+	Symbol        string       `json:"symbol"`         // Símbolo que se muestra antes del monto
+	Thousands     string       `json:"thousands"`      // Separador de miles
+	Decimal       string       `json:"decimal"`        // Separador decimal
+	CashIncrement Amount       `json:"cash_increment"` // Menor múltiplo que se puede pagar en efectivo (0 o 1 no redondea)
+	CashRounding  RoundingMode `json:"cash_rounding"`  // Cómo se redondea un pago en efectivo al múltiplo
+}
+
+// Monedas conocidas. En Chile los pagos en efectivo se redondean a la decena: de 1 a 5 hacia abajo
+// y de 6 a 9 hacia arriba.
+var currencies = map[string]Currency{
+	"CLP": {Code: "CLP", Exponent: 0, Symbol: "$", Thousands: ".", Decimal: ",", CashIncrement: 10, CashRounding: HalfDown},
+	"USD": {Code: "USD", Exponent: 2, Symbol: "US$", Thousands: ",", Decimal: ".", CashIncrement: 1, CashRounding: HalfUp},
+	"EUR": {Code: "EUR", Exponent: 2, Symbol: "€", Thousands: ".", Decimal: ",", CashIncrement: 1, CashRounding: HalfUp},
+	"ARS": {Code: "ARS", Exponent: 2, Symbol: "$", Thousands: ".", Decimal: ",", CashIncrement: 1, CashRounding: HalfUp},
+	"PEN": {Code: "PEN", Exponent: 2, Symbol: "S/", Thousands: ",", Decimal: ".", CashIncrement: 10, CashRounding: Down},
+	"MXN": {Code: "MXN", Exponent: 2, Symbol: "$", Thousands: ",", Decimal: ".", CashIncrement: 1, CashRounding: HalfUp},
+}
+
+// Lookup devuelve la moneda con el código ISO 4217 dado.
+func Lookup(code string) (Currency, error) {
+	currency, ok := currencies[strings.ToUpper(code)]
+	if !ok {
+		return Currency{}, fmt.Errorf("unsupported currency %q", code)
+	}
+	return currency, nil
+}
+
+// Money devuelve el monto en esta moneda.
+func (c Currency) Money(amount Amount) Money {
+	return Money{Amount: amount, Currency: c.Code}
+}
+
+// RoundCash redondea un monto a lo que se puede pagar en efectivo.
+func (c Currency) RoundCash(amount Amount) Amount {
+	if c.CashIncrement <= 1 {
+		return amount
+	}
+	return Round(amount, c.CashIncrement, c.CashRounding)
+}
+
+// Format escribe el monto con símbolo y separadores para recibos y pantallas: "$12.990" o "US$1,234.50".
+func (c Currency) Format(amount Amount) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+	return sign + c.Symbol + c.FormatNumber(abs(amount))
+}
+
+// FormatNumber escribe el monto con separadores pero sin símbolo, para columnas de recibos.
+func (c Currency) FormatNumber(amount Amount) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = abs(amount)
+	}
+	major, minor := c.split(amount)
+
+	digits := strconv.FormatInt(major, 10)
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(c.Thousands)
+		}
+		grouped.WriteRune(digit)
+	}
+	if c.Exponent == 0 {
+		return sign + grouped.String()
+	}
+	return fmt.Sprintf("%s%s%s%0*d", sign, grouped.String(), c.Decimal, c.Exponent, minor)
+}
+
+// PlainString escribe el monto en unidades mayores con punto decimal y sin separadores de miles
+// ("1234.50"), como lo lee ParsePlain. Se usa en archivos que se vuelven a importar.
+func (c Currency) PlainString(amount Amount) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = abs(amount)
+	}
+	major, minor := c.split(amount)
+	if c.Exponent == 0 {
+		return fmt.Sprintf("%s%d", sign, major)
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, major, c.Exponent, minor)
+}
+
+// ParsePlain lee un monto en unidades mayores con punto decimal ("1234.5" o "1234"). Rechaza más
+// decimales de los que tiene la moneda en vez de redondearlos.
+func (c Currency) ParsePlain(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	if whole == "" || (hasFraction && fraction == "") {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(fraction) > c.Exponent {
+		return 0, fmt.Errorf("%s amounts have at most %d decimals", c.Code, c.Exponent)
+	}
+	fraction += strings.Repeat("0", c.Exponent-len(fraction))
+
+	value, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if negative {
+		value = -value
+	}
+	return Amount(value), nil
+}
+
+// split separa un monto positivo en unidades mayores y menores.
+func (c Currency) split(amount Amount) (int64, int64) {
+	unit := int64(1)
+	for i := 0; i < c.Exponent; i++ {
+		unit *= 10
+	}
+	return int64(amount) / unit, int64(amount) % unit
+}
+
+func abs(a Amount) Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// RoundingMode es la regla para redondear un resultado que no cae en un monto exacto.
+type RoundingMode string
+
+const (
+	HalfUp   RoundingMode = "half_up"   // La mitad se aleja de cero
+	HalfDown RoundingMode = "half_down" // La mitad se acerca a cero
+	HalfEven RoundingMode = "half_even" // La mitad va al par (redondeo bancario)
+	Down     RoundingMode = "down"      // Siempre hacia cero
+	Up       RoundingMode = "up"        // Siempre lejos de cero
+)
+
+// ParseRoundingMode valida el nombre de una regla de redondeo.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch mode := RoundingMode(s); mode {
+	case HalfUp, HalfDown, HalfEven, Down, Up:
+		return mode, nil
+	}
+	return "", errors.New("invalid rounding mode, use half_up, half_down, half_even, down or up")
+}
+
+// Round redondea el monto a un múltiplo de increment.
+func Round(amount Amount, increment Amount, mode RoundingMode) Amount {
+	if increment <= 1 {
+		return amount
+	}
+	return Amount(divRound(int64(amount), int64(increment), mode)) * increment
+}
+
+// divRound divide n por d (distinto de cero) redondeando el cociente según mode.
+func divRound(n, d int64, mode RoundingMode) int64 {
+	if d < 0 {
+		n, d = -n, -d
+	}
+	quotient, remainder := n/d, n%d
+	if remainder == 0 {
+		return quotient
+	}
+
+	// Dirección en que se aleja de cero
+	step := int64(1)
+	if n < 0 {
+		step = -1
+		remainder = -remainder
+	}
+
+	switch mode {
+	case Down:
+		return quotient
+	case Up:
+		return quotient + step
+	}
+
+	switch twice := remainder * 2; {
+	case twice > d:
+		return quotient + step
+	case twice < d:
+		return quotient
+	}
+	// Justo la mitad
+	switch mode {
+	case HalfDown:
+		return quotient
+	case HalfEven:
+		if quotient%2 == 0 {
+			return quotient
+		}
+		return quotient + step
+	default:
+		return quotient + step
+	}
+}
@@ -0,0 +1,184 @@
+// Package printing imprime los tickets de cocina en las impresoras térmicas de cada estación.
+// Los tickets se guardan como models.PrintJob en la misma transacción que los items, y un Worker
+// los envía por TCP (puerto 9100, el modo RAW de las impresoras de red) o a un archivo,
+// reintentando con esperas crecientes hasta dejarlos como fallidos.
+package printing
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+)
+
+// Puerto por defecto de las impresoras de red (RAW/JetDirect).
+const DefaultPort = "9100"
+
+// Tiempo máximo para conectar con la impresora y enviarle un ticket.
+const sendTimeout = 5 * time.Second
+
+// Plazo de un trabajo Enviando antes de que otro proceso pueda volver a tomarlo.
+const sendLease = time.Minute
+
+// Espera máxima entre dos intentos, por más reintentos que lleve.
+const maxRetryDelay = 5 * time.Minute
+
+// Settings son las estaciones con impresora de cada sucursal y las reglas de la cola de impresión.
+type Settings struct {
+	Printers       map[uint]map[string]string // Impresora de cada estación por sucursal: tcp://host[:9100] o file:///ruta
+	Categories     map[string]string          // Estación que prepara cada categoría del menú
+	DefaultStation string                     // Estación de las categorías sin una asignada
+	Width          string                     // Ancho del papel: 58mm u 80mm
+	MaxAttempts    int                        // Intentos antes de dejar el trabajo como fallido
+	RetryDelay     time.Duration              // Espera antes del primer reintento; se duplica en cada uno
+}
+
+// StationFor devuelve la estación que prepara los productos de la categoría.
+func (s Settings) StationFor(category string) string {
+	if station, ok := s.Categories[strings.ToLower(strings.TrimSpace(category))]; ok {
+		return station
+	}
+	return s.DefaultStation
+}
+
+// Printer devuelve el destino de la impresora de la estación en la sucursal.
+func (s Settings) Printer(branchID uint, station string) (string, bool) {
+	uri, ok := s.Printers[branchID][station]
+	return uri, ok
+}
+
+// HasPrinter indica si la estación de la sucursal imprime sus tickets. Las que no tienen
+// impresora usan solo la vista de cocina.
+func (s Settings) HasPrinter(branchID uint, station string) bool {
+	_, ok := s.Printer(branchID, station)
+	return ok
+}
+
+// Target es el destino de los tickets de una estación.
+type Target interface {
+	Send(data []byte) error
+}
+
+// ParseTarget interpreta el destino de una impresora: tcp://host[:puerto] para una impresora de
+// red, o file:///ruta para agregar los tickets a un archivo, útil para probar sin impresora.
+func ParseTarget(uri string) (Target, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid printer %q: %w", uri, err)
+	}
+	switch u.Scheme {
+	case "tcp":
+		if u.Hostname() == "" {
+			return nil, fmt.Errorf("invalid printer %q: missing host", uri)
+		}
+		port := u.Port()
+		if port == "" {
+			port = DefaultPort
+		}
+		return tcpTarget(net.JoinHostPort(u.Hostname(), port)), nil
+	case "file":
+		path := u.Path
+		if path == "" {
+			path = u.Opaque
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid printer %q: missing path", uri)
+		}
+		return fileTarget(path), nil
+	default:
+		return nil, fmt.Errorf("invalid printer %q: use tcp://host:9100 or file:///path", uri)
+	}
+}
+
+// tcpTarget envía el ticket en crudo a una impresora de red.
+type tcpTarget string
+
+func (t tcpTarget) Send(data []byte) error {
+	conn, err := net.DialTimeout("tcp", string(t), sendTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(sendTimeout)); err != nil {
+		return err
+	}
+	_, err = conn.Write(data)
+	return err
+}
+
+// fileTarget agrega el ticket al final de un archivo.
+type fileTarget string
+
+func (t fileTarget) Send(data []byte) error {
+	f, err := os.OpenFile(string(t), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// errNoPrinter se anota en los trabajos de una estación que ya no tiene impresora configurada.
+var errNoPrinter = errors.New("the station has no printer configured")
+
+// Claim marca el trabajo como Enviando y cuenta el intento. El plazo sendLease evita que otro
+// proceso lo tome mientras se envía; si vence sin resultado, el trabajo vuelve a tomarse.
+func (s Settings) Claim(job *models.PrintJob, now time.Time) {
+	job.Estado = models.PrintJobEnviando
+	job.Attempts++
+	job.NextAttemptAt = now.Add(sendLease)
+}
+
+// Send envía el trabajo a la impresora de su estación en su sucursal. No toca la base de datos,
+// así que se llama fuera de las transacciones.
+func (s Settings) Send(job models.PrintJob) error {
+	uri, ok := s.Printer(job.BranchID, job.Station)
+	if !ok {
+		return errNoPrinter
+	}
+	target, err := ParseTarget(uri)
+	if err != nil {
+		return err
+	}
+	return target.Send(job.Content)
+}
+
+// record anota el resultado del intento contado por Claim: impreso, otro intento más tarde o
+// fallido si se agotaron los reintentos.
+func (s Settings) record(job *models.PrintJob, err error, now time.Time) {
+	if err == nil {
+		job.Estado = models.PrintJobImpreso
+		job.LastError = ""
+		job.PrintedAt = &now
+		return
+	}
+
+	job.LastError = err.Error()
+	if job.Attempts >= s.MaxAttempts {
+		job.Estado = models.PrintJobFallido
+		return
+	}
+	job.Estado = models.PrintJobPendiente
+	job.NextAttemptAt = now.Add(s.retryDelay(job.Attempts))
+}
+
+// retryDelay es la espera después del intento número attempts: RetryDelay, el doble, el
+// cuádruple... hasta maxRetryDelay.
+func (s Settings) retryDelay(attempts int) time.Duration {
+	delay := s.RetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,69 @@
+package printing
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/escpos"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/receipt"
+)
+
+// Ticket es lo que se imprime en una estación al enviarle items de una comanda.
+type Ticket struct {
+	Station string
+	OrderID uint
+	Table   int
+	Date    time.Time
+	Items   []models.OrderItem
+}
+
+// ESCPOS arma el ticket para la impresora, con letra grande para la mesa y los productos, que
+// se leen a distancia en la cocina, agrupados por tiempo del menú.
+func (t Ticket) ESCPOS(width string) []byte {
+	columns := receipt.Papers["80mm"].Columns
+	if paper, ok := receipt.Papers[width]; ok {
+		columns = paper.Columns
+	}
+	separator := strings.Repeat("-", columns)
+
+	w := escpos.New()
+	w.Align(escpos.Center).Bold(true).Line(strings.ToUpper(t.Station)).Bold(false)
+	w.Double(true).Line(fmt.Sprintf("Mesa %d", t.Table)).Double(false)
+	w.Line(fmt.Sprintf("Comanda #%d - %s", t.OrderID, t.Date.Format("15:04")))
+	w.Align(escpos.Left).Line(separator)
+
+	// Los items van agrupados por tiempo del menú
+	items := append([]models.OrderItem(nil), t.Items...)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Course < items[j].Course })
+	course := 0
+	for _, item := range items {
+		if item.Course != course {
+			course = item.Course
+			w.Bold(true).Line(fmt.Sprintf("TIEMPO %d", course)).Bold(false)
+		}
+		name := item.ProductName
+		if name == "" {
+			name = item.Product.Name
+		}
+		w.Double(true).Line(fmt.Sprintf("%d x %s", item.Quantity, name)).Double(false)
+		for _, modifier := range item.Modifiers {
+			w.Line("  + " + modifier)
+		}
+		if item.Notes != "" {
+			w.Line("  Nota: " + item.Notes)
+		}
+		if len(item.Allergens) > 0 {
+			w.Bold(true).Line("  ALÉRGENOS: " + strings.Join(item.Allergens, ", ")).Bold(false)
+		}
+	}
+	return w.Line(separator).Feed(3).Cut().Bytes()
+}
+
+// Reprint antepone al ticket un aviso de reimpresión, para que la cocina no lo prepare dos veces.
+func Reprint(content []byte) []byte {
+	banner := escpos.New().Align(escpos.Center).Bold(true).Line("*** REIMPRESIÓN ***").Bold(false).Align(escpos.Left).Bytes()
+	return append(banner, content...)
+}
@@ -0,0 +1,104 @@
+package printing
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/repository"
+)
+
+// Trabajos que envía el Worker por sucursal en cada vuelta.
+const batchSize = 20
+
+// Worker envía los tickets pendientes de todas las sucursales a sus impresoras.
+type Worker struct {
+	Store    repository.Store
+	Settings Settings
+	Interval time.Duration // Cada cuánto busca trabajos pendientes
+}
+
+// Run envía los trabajos pendientes cada Interval hasta que se cancele ctx.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		if err := w.RunOnce(time.Now()); err != nil {
+			log.Println("Error sending print jobs:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce envía los trabajos pendientes cuyo intento ya llegó. Por cada sucursal los toma en una
+// transacción corta, los envía sin la transacción abierta y anota cada resultado aparte, así dos
+// réplicas no imprimen el mismo ticket y una impresora lenta no deja filas bloqueadas.
+func (w *Worker) RunOnce(now time.Time) error {
+	branches, err := w.Store.Branches().FindAll()
+	if err != nil {
+		return err
+	}
+	for _, branch := range branches {
+		store := w.Store.Branch(branch.ID)
+		var jobs []models.PrintJob
+		err := store.Transaction(func(tx repository.Store) error {
+			var err error
+			jobs, err = tx.PrintJobs().ClaimDue(now, batchSize)
+			if err != nil {
+				return err
+			}
+			for i := range jobs {
+				w.Settings.Claim(&jobs[i], now)
+				if err := tx.PrintJobs().Save(&jobs[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range jobs {
+			sendErr := w.Settings.Send(jobs[i])
+			if sendErr != nil {
+				log.Printf("Print job %d for %s failed (attempt %d): %v", jobs[i].ID, jobs[i].Station, jobs[i].Attempts, sendErr)
+			}
+			if _, err := w.Settings.Finish(store, jobs[i], sendErr, time.Now()); err != nil {
+				log.Printf("Print job %d: %v", jobs[i].ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ErrClaimLost indica que otro proceso volvió a tomar el trabajo porque venció su plazo de envío.
+var ErrClaimLost = errors.New("print job was claimed again by another sender")
+
+// Finish anota en su propia transacción el resultado de enviar un trabajo tomado con Claim. Si
+// mientras tanto otro proceso lo volvió a tomar, no lo toca y devuelve ErrClaimLost.
+func (s Settings) Finish(store repository.Store, claimed models.PrintJob, sendErr error, now time.Time) (*models.PrintJob, error) {
+	var job *models.PrintJob
+	err := store.Transaction(func(tx repository.Store) error {
+		var err error
+		job, err = tx.PrintJobs().FindByIDForUpdate(claimed.ID)
+		if err != nil {
+			return err
+		}
+		if job.Estado != models.PrintJobEnviando || job.Attempts != claimed.Attempts {
+			return ErrClaimLost
+		}
+		s.record(job, sendErr, now)
+		return tx.PrintJobs().Save(job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
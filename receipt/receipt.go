@@ -0,0 +1,319 @@
+// Package receipt genera la pre-cuenta y el comprobante de una comanda para imprimir: texto
+// plano para papel de 58 u 80 mm, ESC/POS para impresoras térmicas y PDF.
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+)
+
+// Settings son los datos del restaurante y las reglas que se imprimen en todos los recibos.
+type Settings struct {
+	Header      []string // Líneas del encabezado: nombre, RUT, dirección...
+	Footer      []string // Líneas del pie
+	TaxName     string   // Nombre del impuesto incluido en los precios, como "IVA"
+	TaxRate     int      // Tasa del impuesto en puntos básicos (1900 = 19%); 0 no lo imprime
+	TipPercents []int    // Porcentajes de propina sugerida
+}
+
+// Line es un producto del recibo.
+type Line struct {
+	Quantity  int
+	Name      string
+	Modifiers []string
+	Notes     string
+	Amount    money.Amount
+	Comp      bool // Cortesía: se imprime pero se descuenta del total
+}
+
+// Tip es una propina sugerida.
+type Tip struct {
+	Percent int
+	Amount  money.Amount
+}
+
+// Document es el contenido del recibo, independiente del formato en que se imprime.
+type Document struct {
+	Settings  Settings
+	Branch    string
+	Title     string
+	OrderID   uint
+	Table     int
+	Covers    int
+	Date      time.Time
+	Currency  money.Currency
+	Lines     []Line
+	Subtotal  money.Amount
+	Discounts money.Amount // Cortesías
+	Total     money.Amount
+	Tax       money.Amount // Impuesto incluido en el total
+	Tips      []Tip
+	Payments  []models.Payment
+	Paid      money.Amount // Pagos más redondeos
+	Balance   money.Amount // Lo que falta pagar
+}
+
+// FromOrder arma el recibo de la comanda con sus pagos. Los items anulados no se imprimen.
+// Si la comanda no está pagada el recibo es una pre-cuenta.
+func FromOrder(order models.Order, payments []models.Payment, branch string, currency money.Currency, settings Settings, rounding money.RoundingMode, date time.Time) Document {
+	doc := Document{
+		Settings: settings,
+		Branch:   branch,
+		Title:    "PRE-CUENTA",
+		OrderID:  order.ID,
+		Table:    order.TableNumber,
+		Covers:   order.Covers,
+		Date:     date,
+		Currency: currency,
+		Total:    order.TotalAmount,
+		Payments: payments,
+	}
+	if order.Estado == "Pagada" {
+		doc.Title = "COMPROBANTE"
+	}
+
+	for _, item := range order.Items {
+		if item.Estado == models.OrderItemAnulado {
+			continue
+		}
+		name := item.ProductName
+		if name == "" {
+			name = item.Product.Name
+		}
+		line := Line{
+			Quantity:  item.Quantity,
+			Name:      name,
+			Modifiers: item.Modifiers,
+			Notes:     item.Notes,
+			Amount:    item.TotalPrice,
+			Comp:      item.Estado == models.OrderItemCortesia,
+		}
+		doc.Lines = append(doc.Lines, line)
+		doc.Subtotal += line.Amount
+		if line.Comp {
+			doc.Discounts += line.Amount
+		}
+	}
+
+	if settings.TaxRate > 0 {
+		// Los precios incluyen el impuesto: neto = total / (1 + tasa)
+		net := doc.Total.Mul(10000).Div(10000+settings.TaxRate, rounding)
+		doc.Tax = doc.Total - net
+	}
+	for _, percent := range settings.TipPercents {
+		doc.Tips = append(doc.Tips, Tip{Percent: percent, Amount: doc.Total.Percent(percent*100, rounding)})
+	}
+	for _, p := range payments {
+		doc.Paid += p.Amount + p.Rounding
+	}
+	doc.Balance = doc.Total - doc.Paid
+	return doc
+}
+
+// Paper es un ancho de papel: caracteres por línea en la fuente normal y ancho en puntos para el PDF.
+type Paper struct {
+	Columns int
+	Points  float64
+}
+
+// Papers son los anchos de papel admitidos.
+var Papers = map[string]Paper{
+	"58mm": {Columns: 32, Points: 164.4},
+	"80mm": {Columns: 48, Points: 226.8},
+}
+
+// Formatos de salida y su tipo de contenido.
+var Formats = map[string]string{
+	"text":   "text/plain; charset=utf-8",
+	"escpos": "application/vnd.escpos",
+	"pdf":    "application/pdf",
+}
+
+// Render genera el recibo en el formato y ancho de papel pedidos.
+func Render(doc Document, format string, width string) ([]byte, error) {
+	paper, ok := Papers[width]
+	if !ok {
+		return nil, fmt.Errorf("unsupported paper width %q, use 58mm or 80mm", width)
+	}
+	rows := layout(doc, paper.Columns)
+	switch format {
+	case "text":
+		return []byte(renderText(rows, paper.Columns)), nil
+	case "escpos":
+		return renderESCPOS(rows), nil
+	case "pdf":
+		return renderPDF(rows, paper), nil
+	default:
+		return nil, fmt.Errorf("unsupported receipt format %q, use text, escpos or pdf", format)
+	}
+}
+
+// row es una línea impresa. Las de dos columnas ya vienen rellenadas al ancho del papel.
+type row struct {
+	text   string
+	center bool
+	bold   bool
+	big    bool // Doble alto y ancho; solo para textos cortos como el título
+}
+
+// layout arma las líneas del recibo para el ancho dado.
+func layout(doc Document, columns int) []row {
+	c := doc.Currency
+	var rows []row
+	add := func(r row) { rows = append(rows, r) }
+	separator := func() { add(row{text: strings.Repeat("-", columns)}) }
+	amount := func(label string, value money.Amount, bold bool) {
+		add(row{text: twoColumns(label, c.Format(value), columns), bold: bold})
+	}
+
+	for _, line := range doc.Settings.Header {
+		add(row{text: line, center: true, bold: true})
+	}
+	if doc.Branch != "" {
+		add(row{text: doc.Branch, center: true})
+	}
+	add(row{})
+	add(row{text: doc.Title, center: true, bold: true, big: true})
+	add(row{})
+	add(row{text: twoColumns(fmt.Sprintf("Mesa %d", doc.Table), fmt.Sprintf("Comanda #%d", doc.OrderID), columns)})
+	if doc.Covers > 0 {
+		add(row{text: fmt.Sprintf("Comensales: %d", doc.Covers)})
+	}
+	add(row{text: doc.Date.Format("02/01/2006 15:04")})
+	separator()
+
+	for _, line := range doc.Lines {
+		name := line.Name
+		if line.Comp {
+			name += " (cortesía)"
+		}
+		label := fmt.Sprintf("%d %s", line.Quantity, name)
+		price := c.Format(line.Amount)
+		wrapped := wrap(label, columns-runeLen(price)-1)
+		add(row{text: twoColumns(wrapped[0], price, columns)})
+		for _, rest := range wrapped[1:] {
+			add(row{text: "  " + rest})
+		}
+		for _, modifier := range line.Modifiers {
+			for _, text := range wrap("+ "+modifier, columns-2) {
+				add(row{text: "  " + text})
+			}
+		}
+		if line.Notes != "" {
+			for _, text := range wrap("Nota: "+line.Notes, columns-2) {
+				add(row{text: "  " + text})
+			}
+		}
+	}
+	separator()
+
+	amount("Subtotal", doc.Subtotal, false)
+	if doc.Discounts > 0 {
+		amount("Cortesías", -doc.Discounts, false)
+	}
+	amount("TOTAL", doc.Total, true)
+	if doc.Settings.TaxRate > 0 {
+		amount(fmt.Sprintf("%s incluido (%s%%)", doc.Settings.TaxName, percent(doc.Settings.TaxRate)), doc.Tax, false)
+		amount("Neto", doc.Total-doc.Tax, false)
+	}
+
+	if len(doc.Tips) > 0 && doc.Balance > 0 {
+		separator()
+		for _, tip := range doc.Tips {
+			amount(fmt.Sprintf("Propina sugerida (%d%%)", tip.Percent), tip.Amount, false)
+			amount("Total con propina", doc.Total+tip.Amount, false)
+		}
+	}
+
+	if len(doc.Payments) > 0 {
+		separator()
+		add(row{text: "Pagos", bold: true})
+		for _, p := range doc.Payments {
+			amount(p.Tender, p.Amount, false)
+			if p.Tip > 0 {
+				amount("  Propina", p.Tip, false)
+			}
+			if p.Rounding != 0 {
+				amount("  Redondeo", p.Rounding, false)
+			}
+		}
+		amount("Saldo", doc.Balance, true)
+	}
+
+	if len(doc.Settings.Footer) > 0 {
+		add(row{})
+		for _, line := range doc.Settings.Footer {
+			add(row{text: line, center: true})
+		}
+	}
+	return rows
+}
+
+// twoColumns alinea label a la izquierda y value a la derecha en el ancho dado. Si no caben,
+// recorta label.
+func twoColumns(label string, value string, columns int) string {
+	space := columns - runeLen(value) - 1
+	if space < 0 {
+		space = 0
+	}
+	label = truncate(label, space)
+	return label + strings.Repeat(" ", columns-runeLen(label)-runeLen(value)) + value
+}
+
+// wrap corta el texto en líneas de hasta width caracteres, por palabras.
+func wrap(text string, width int) []string {
+	if width <= 0 {
+		return []string{text}
+	}
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for runeLen(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			r := []rune(word)
+			lines = append(lines, string(r[:width]))
+			word = string(r[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case runeLen(current)+1+runeLen(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+func truncate(text string, width int) string {
+	r := []rune(text)
+	if len(r) <= width {
+		return text
+	}
+	return string(r[:width])
+}
+
+func runeLen(text string) int {
+	return len([]rune(text))
+}
+
+// percent escribe una tasa en puntos básicos como porcentaje: 1900 es "19" y 1050 es "10,5".
+func percent(basisPoints int) string {
+	text := fmt.Sprintf("%d", basisPoints/100)
+	if decimals := basisPoints % 100; decimals != 0 {
+		text += "," + strings.TrimRight(fmt.Sprintf("%02d", decimals), "0")
+	}
+	return text
+}
@@ -0,0 +1,137 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/escpos"
+)
+
+// renderText escribe el recibo como texto plano, centrando con espacios.
+func renderText(rows []row, columns int) string {
+	var out strings.Builder
+	for _, r := range rows {
+		text := r.text
+		if r.center {
+			if pad := (columns - runeLen(text)) / 2; pad > 0 {
+				text = strings.Repeat(" ", pad) + text
+			}
+		}
+		out.WriteString(strings.TrimRight(text, " "))
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+// renderESCPOS escribe el recibo para una impresora térmica y corta el papel al final.
+func renderESCPOS(rows []row) []byte {
+	w := escpos.New()
+	for _, r := range rows {
+		if r.center {
+			w.Align(escpos.Center)
+		}
+		if r.bold {
+			w.Bold(true)
+		}
+		if r.big {
+			w.Double(true)
+		}
+		w.Line(r.text)
+		if r.big {
+			w.Double(false)
+		}
+		if r.bold {
+			w.Bold(false)
+		}
+		if r.center {
+			w.Align(escpos.Left)
+		}
+	}
+	return w.Feed(3).Cut().Bytes()
+}
+
+// Márgenes y alto de línea del PDF, en puntos.
+const (
+	pdfMargin      = 8.0
+	pdfLineSpacing = 1.3
+)
+
+// renderPDF escribe el recibo como un PDF de una página del ancho del papel, en Courier para que
+// las columnas queden alineadas como en la impresora.
+func renderPDF(rows []row, paper Paper) []byte {
+	// En Courier cada carácter mide 0,6 veces el tamaño de la fuente
+	size := (paper.Points - 2*pdfMargin) / (0.6 * float64(paper.Columns))
+	leading := size * pdfLineSpacing
+
+	height := 2 * pdfMargin
+	for _, r := range rows {
+		if r.big {
+			height += 2 * leading
+		} else {
+			height += leading
+		}
+	}
+
+	var content bytes.Buffer
+	y := height - pdfMargin
+	for _, r := range rows {
+		fontSize := size
+		if r.big {
+			fontSize = 2 * size
+		}
+		y -= fontSize * pdfLineSpacing
+		if r.text == "" {
+			continue
+		}
+		x := pdfMargin
+		if r.center {
+			x += (paper.Points - 2*pdfMargin - 0.6*fontSize*float64(runeLen(r.text))) / 2
+		}
+		font := "F1"
+		if r.bold {
+			font = "F2"
+		}
+		fmt.Fprintf(&content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, fontSize, x, y, pdfString(r.text))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", paper.Points, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
+
+// pdfString codifica el texto en WinAnsiEncoding y escapa los caracteres especiales de las
+// cadenas de PDF.
+func pdfString(text string) string {
+	var out strings.Builder
+	for _, b := range escpos.EncodeCP1252(text) {
+		switch b {
+		case '(', ')', '\\':
+			out.WriteByte('\\')
+			out.WriteByte(b)
+		default:
+			out.WriteByte(b)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,494 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GormStore implementa Store sobre una conexión (o transacción) de GORM.
+type GormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore crea un Store que usa la conexión dada.
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Products() ProductRepository         { return gormProducts{s.db} }
+func (s *GormStore) Orders() OrderRepository             { return gormOrders{s.db} }
+func (s *GormStore) OrderItems() OrderItemRepository     { return gormOrderItems{s.db} }
+func (s *GormStore) Payments() PaymentRepository         { return gormPayments{s.db} }
+func (s *GormStore) Shifts() ShiftRepository             { return gormShifts{s.db} }
+func (s *GormStore) Translations() TranslationRepository { return gormTranslations{s.db} }
+func (s *GormStore) Images() ImageRepository             { return gormImages{s.db} }
+func (s *GormStore) PrintJobs() PrintJobRepository       { return gormPrintJobs{s.db} }
+func (s *GormStore) Audit() AuditRepository              { return gormAudit{s.db} }
+func (s *GormStore) Branches() BranchRepository          { return gormBranches{s.db} }
+
+// Branch limita las consultas con los callbacks del paquete tenant.
+func (s *GormStore) Branch(branchID uint) Store {
+	return NewGormStore(tenant.Scope(s.db, branchID))
+}
+
+func (s *GormStore) Transaction(fn func(store Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGormStore(tx))
+	})
+}
+
+// notFound traduce el error de GORM por ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormProducts struct{ db *gorm.DB }
+
+func (r gormProducts) FindByID(id uint) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.First(&product, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &product, nil
+}
+
+func (r gormProducts) FindByName(name string) (*models.Product, error) {
+	var product models.Product
+	if err := r.db.Where("name = ?", name).Order("branch_id DESC").First(&product).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &product, nil
+}
+
+func (r gormProducts) Create(product *models.Product) error {
+	return r.db.Omit(clause.Associations).Create(product).Error
+}
+
+func (r gormProducts) Save(product *models.Product) error {
+	if product.ID == 0 {
+		return r.Create(product)
+	}
+	// Updates en vez de Save: si la sucursal no es dueña del producto no se actualiza ninguna
+	// fila y Save intentaría insertarlo
+	result := r.db.Model(product).Omit(clause.Associations).Select("*").Updates(product)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return result.Error
+}
+
+func (r gormProducts) FindOverride(productID uint) (*models.ProductOverride, error) {
+	var override models.ProductOverride
+	if err := r.db.Where("product_id = ?", productID).First(&override).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &override, nil
+}
+
+func (r gormProducts) SaveOverride(override *models.ProductOverride) error {
+	return r.db.Save(override).Error
+}
+
+func (r gormProducts) DeleteOverride(override *models.ProductOverride) error {
+	return r.db.Delete(override).Error
+}
+
+func (r gormProducts) RecordPrice(productID uint, price money.Amount, userID uint, at time.Time) error {
+	if err := r.db.Model(&models.ProductPrice{}).
+		Where("product_id = ? AND effective_to IS NULL", productID).
+		Update("effective_to", at).Error; err != nil {
+		return fmt.Errorf("failed to close current price: %w", err)
+	}
+	entry := models.ProductPrice{
+		ProductID:     productID,
+		Price:         price,
+		EffectiveFrom: at,
+		ChangedBy:     userID,
+	}
+	if err := r.db.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record price history: %w", err)
+	}
+	return nil
+}
+
+type gormOrders struct{ db *gorm.DB }
+
+func (r gormOrders) FindByID(id uint) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.Preload("Items.Product").First(&order, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &order, nil
+}
+
+func (r gormOrders) FindByIDForUpdate(id uint) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items.Product").First(&order, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &order, nil
+}
+
+func (r gormOrders) FindOpenByTable(tableNumber int) (*models.Order, error) {
+	var order models.Order
+	if err := r.db.Preload("Items.Product").Where("table_number = ? AND estado = ?", tableNumber, "Pendiente").First(&order).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &order, nil
+}
+
+func (r gormOrders) FindActive() ([]models.Order, error) {
+	var orders []models.Order
+	if err := r.db.Preload("Items.Product").Where("estado = ?", "Pendiente").Order("table_number").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r gormOrders) FindByUser(userID uint) ([]models.Order, error) {
+	var orders []models.Order
+	if err := r.db.Preload("Items.Product").Where("user_id = ?", userID).Order("order_date DESC").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r gormOrders) Create(order *models.Order) error {
+	if order.Version == 0 {
+		order.Version = 1
+	}
+	return r.db.Omit(clause.Associations).Create(order).Error
+}
+
+// FindOrCreateOpen inserta la comanda solo si la mesa de la sucursal no tiene una pendiente (índice
+// único parcial idx_orders_open_table) y si ya tenía una la bloquea hasta el fin de la transacción, para que
+// quien la cree primero gane y los demás agreguen sus items a la misma.
+func (r gormOrders) FindOrCreateOpen(order *models.Order) (*models.Order, bool, error) {
+	if order.Version == 0 {
+		order.Version = 1
+	}
+	result := r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "branch_id"}, {Name: "table_number"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "estado = 'Pendiente'"}}},
+		DoNothing:   true,
+	}).Create(order)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return order, true, nil
+	}
+
+	var open models.Order
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("table_number = ? AND estado = ?", order.TableNumber, "Pendiente").
+		First(&open).Error; err != nil {
+		return nil, false, notFound(err)
+	}
+	return &open, false, nil
+}
+
+func (r gormOrders) Save(order *models.Order) error {
+	if order.ID == 0 {
+		return r.Create(order)
+	}
+
+	read := order.Version
+	order.Version++
+	result := r.db.Model(order).Omit(clause.Associations).Where("version = ?", read).Select("*").Updates(order)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrConflict
+	}
+	if result.Error != nil {
+		order.Version = read
+		return result.Error
+	}
+	return nil
+}
+
+type gormOrderItems struct{ db *gorm.DB }
+
+func (r gormOrderItems) FindByID(id uint) (*models.OrderItem, error) {
+	var item models.OrderItem
+	if err := r.db.Preload("Product").First(&item, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &item, nil
+}
+
+func (r gormOrderItems) FindByOrder(orderID uint) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	if err := r.db.Where("order_id = ?", orderID).Order("id").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r gormOrderItems) FindForKitchen() ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.Preload("Product").
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.estado = ?", "Pendiente").
+		Where("NOT order_items.held AND order_items.fired_at IS NOT NULL").
+		Where("order_items.estado <> ? AND order_items.kitchen_status <> ?", models.OrderItemAnulado, models.KitchenListo).
+		Order("order_items.fired_at, order_items.id").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r gormOrderItems) FindVoided(from time.Time, to time.Time) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	err := r.db.Where("estado IN ? AND voided_at >= ? AND voided_at <= ?", []string{models.OrderItemAnulado, models.OrderItemCortesia}, from, to).
+		Order("voided_at, id").Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r gormOrderItems) Create(item *models.OrderItem) error {
+	return r.db.Omit(clause.Associations).Create(item).Error
+}
+
+func (r gormOrderItems) Save(item *models.OrderItem) error {
+	return r.db.Omit(clause.Associations).Save(item).Error
+}
+
+func (r gormOrderItems) Delete(item *models.OrderItem) error {
+	return r.db.Delete(item).Error
+}
+
+type gormPayments struct{ db *gorm.DB }
+
+func (r gormPayments) FindByOrder(orderID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r gormPayments) FindByShift(shiftID uint) ([]models.Payment, error) {
+	var payments []models.Payment
+	if err := r.db.Where("shift_id = ?", shiftID).Order("created_at, id").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r gormPayments) Create(payment *models.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+type gormShifts struct{ db *gorm.DB }
+
+func (r gormShifts) FindOpen() (*models.Shift, error) {
+	var shift models.Shift
+	if err := r.db.Where("estado = ?", models.ShiftAbierto).First(&shift).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &shift, nil
+}
+
+// CreateOpen usa el índice único parcial idx_shifts_open_branch: la segunda de dos aperturas
+// simultáneas no inserta nada.
+func (r gormShifts) CreateOpen(shift *models.Shift) (bool, error) {
+	result := r.db.Omit(clause.Associations).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "branch_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "estado = 'Abierto'"}}},
+		DoNothing:   true,
+	}).Create(shift)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r gormShifts) Save(shift *models.Shift) error {
+	return r.db.Omit(clause.Associations).Save(shift).Error
+}
+
+func (r gormShifts) FindZReport(shiftID uint) (*models.ZReport, error) {
+	var report models.ZReport
+	if err := r.db.Where("shift_id = ?", shiftID).First(&report).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &report, nil
+}
+
+func (r gormShifts) CreateZReport(report *models.ZReport) error {
+	return r.db.Create(report).Error
+}
+
+type gormTranslations struct{ db *gorm.DB }
+
+func (r gormTranslations) FindProduct(productID uint, locale string) (*models.ProductTranslation, error) {
+	var translation models.ProductTranslation
+	if err := r.db.Where("product_id = ? AND locale = ?", productID, locale).Order("branch_id DESC").First(&translation).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &translation, nil
+}
+
+func (r gormTranslations) FindByProduct(productID uint) ([]models.ProductTranslation, error) {
+	var translations []models.ProductTranslation
+	if err := r.db.Where("product_id = ?", productID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (r gormTranslations) ForProducts(locale string, productIDs []uint) ([]models.ProductTranslation, error) {
+	var translations []models.ProductTranslation
+	if err := r.db.Where("locale = ? AND product_id IN ?", locale, productIDs).Order("branch_id").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// SaveProduct devuelve con RETURNING el ID de la fila existente cuando la reemplaza.
+func (r gormTranslations) SaveProduct(translation *models.ProductTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "branch_id"}, {Name: "product_id"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description"}),
+	}, clause.Returning{}).Create(translation).Error
+}
+
+func (r gormTranslations) DeleteProduct(translation *models.ProductTranslation) error {
+	return r.db.Delete(translation).Error
+}
+
+func (r gormTranslations) FindCategory(category string, locale string) (*models.CategoryTranslation, error) {
+	var translation models.CategoryTranslation
+	if err := r.db.Where("category = ? AND locale = ?", category, locale).Order("branch_id DESC").First(&translation).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &translation, nil
+}
+
+func (r gormTranslations) ForCategories(locale string, categories []string) ([]models.CategoryTranslation, error) {
+	var translations []models.CategoryTranslation
+	if err := r.db.Where("locale = ? AND category IN ?", locale, categories).Order("branch_id").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// SaveCategory devuelve con RETURNING el ID de la fila existente cuando la reemplaza.
+func (r gormTranslations) SaveCategory(translation *models.CategoryTranslation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "branch_id"}, {Name: "category"}, {Name: "locale"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name"}),
+	}, clause.Returning{}).Create(translation).Error
+}
+
+type gormImages struct{ db *gorm.DB }
+
+func (r gormImages) FindByID(id uint) (*models.ProductImage, error) {
+	var image models.ProductImage
+	if err := r.db.First(&image, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &image, nil
+}
+
+func (r gormImages) FindByProduct(productID uint) ([]models.ProductImage, error) {
+	images := []models.ProductImage{}
+	if err := r.db.Where("product_id = ?", productID).Order("id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r gormImages) Create(image *models.ProductImage) error {
+	return r.db.Create(image).Error
+}
+
+func (r gormImages) Delete(image *models.ProductImage) error {
+	return r.db.Delete(image).Error
+}
+
+type gormPrintJobs struct{ db *gorm.DB }
+
+func (r gormPrintJobs) FindByID(id uint) (*models.PrintJob, error) {
+	var job models.PrintJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &job, nil
+}
+
+func (r gormPrintJobs) FindByIDForUpdate(id uint) (*models.PrintJob, error) {
+	var job models.PrintJob
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &job, nil
+}
+
+func (r gormPrintJobs) FindByEstado(estado string) ([]models.PrintJob, error) {
+	var jobs []models.PrintJob
+	if err := r.db.Where("estado = ?", estado).Order("created_at, id").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r gormPrintJobs) ClaimDue(now time.Time, limit int) ([]models.PrintJob, error) {
+	var jobs []models.PrintJob
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("estado IN ? AND next_attempt_at <= ?", []string{models.PrintJobPendiente, models.PrintJobEnviando}, now).
+		Order("next_attempt_at, id").Limit(limit).Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r gormPrintJobs) Create(job *models.PrintJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r gormPrintJobs) Save(job *models.PrintJob) error {
+	return r.db.Save(job).Error
+}
+
+type gormBranches struct{ db *gorm.DB }
+
+func (r gormBranches) FindByID(id uint) (*models.Branch, error) {
+	var branch models.Branch
+	if err := r.db.First(&branch, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &branch, nil
+}
+
+func (r gormBranches) FindAll() ([]models.Branch, error) {
+	var branches []models.Branch
+	if err := r.db.Where("id <> ?", tenant.Shared).Order("id").Find(&branches).Error; err != nil {
+		return nil, err
+	}
+	return branches, nil
+}
+
+func (r gormBranches) Create(branch *models.Branch) error {
+	return r.db.Create(branch).Error
+}
+
+type gormAudit struct{ db *gorm.DB }
+
+func (r gormAudit) Record(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
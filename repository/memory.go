@@ -0,0 +1,1126 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/tenant"
+)
+
+// MemoryStore implementa Store en memoria. Sirve para probar la lógica de los controladores
+// sin Postgres: imita los valores por defecto y las precargas de la implementación de GORM.
+//
+// Las transacciones trabajan sobre una copia del estado que reemplaza al original al terminar
+// sin error. Se ejecutan de a una; una escritura hecha fuera de la transacción mientras esta
+// corre se pierde al confirmarla.
+//
+// Las vistas que devuelve Branch comparten el estado y filtran por sucursal como los callbacks
+// del paquete tenant. NewMemoryStore devuelve la vista del catálogo común.
+type MemoryStore struct {
+	mu     *sync.Mutex
+	txMu   *sync.Mutex
+	state  *memoryState
+	branch uint
+}
+
+type memoryState struct {
+	products   map[uint]models.Product
+	orders     map[uint]models.Order
+	orderItems map[uint]models.OrderItem
+	branches   map[uint]models.Branch
+	overrides  map[overrideKey]models.ProductOverride
+	prices     []models.ProductPrice
+	payments   []models.Payment
+	shifts     map[uint]models.Shift
+	zReports   map[uint]models.ZReport
+	printJobs  map[uint]models.PrintJob
+	audit      []models.AuditLog
+	lastID     map[string]uint // Último ID usado por tabla, como las secuencias de Postgres
+
+	productTranslations  map[uint]models.ProductTranslation
+	categoryTranslations map[uint]models.CategoryTranslation
+	images               map[uint]models.ProductImage
+}
+
+type overrideKey struct{ productID, branchID uint }
+
+// NewMemoryStore crea un Store en memoria vacío, con el catálogo común y la casa matriz como
+// los crea la migración de sucursales.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		mu:   &sync.Mutex{},
+		txMu: &sync.Mutex{},
+		state: &memoryState{
+			products:   map[uint]models.Product{},
+			orders:     map[uint]models.Order{},
+			orderItems: map[uint]models.OrderItem{},
+			branches: map[uint]models.Branch{
+				tenant.Shared: {ID: tenant.Shared, Name: "Catálogo común"},
+				1:             {ID: 1, Name: "Casa matriz"},
+			},
+			overrides: map[overrideKey]models.ProductOverride{},
+			shifts:    map[uint]models.Shift{},
+			zReports:  map[uint]models.ZReport{},
+			printJobs: map[uint]models.PrintJob{},
+			lastID:    map[string]uint{"branches": 1},
+
+			productTranslations:  map[uint]models.ProductTranslation{},
+			categoryTranslations: map[uint]models.CategoryTranslation{},
+			images:               map[uint]models.ProductImage{},
+		},
+	}
+}
+
+func (s *MemoryStore) Products() ProductRepository         { return memoryProducts{s} }
+func (s *MemoryStore) Orders() OrderRepository             { return memoryOrders{s} }
+func (s *MemoryStore) OrderItems() OrderItemRepository     { return memoryOrderItems{s} }
+func (s *MemoryStore) Payments() PaymentRepository         { return memoryPayments{s} }
+func (s *MemoryStore) Shifts() ShiftRepository             { return memoryShifts{s} }
+func (s *MemoryStore) Translations() TranslationRepository { return memoryTranslations{s} }
+func (s *MemoryStore) Images() ImageRepository             { return memoryImages{s} }
+func (s *MemoryStore) PrintJobs() PrintJobRepository       { return memoryPrintJobs{s} }
+func (s *MemoryStore) Audit() AuditRepository              { return memoryAudit{s} }
+func (s *MemoryStore) Branches() BranchRepository          { return memoryBranches{s} }
+
+func (s *MemoryStore) Branch(branchID uint) Store {
+	return &MemoryStore{mu: s.mu, txMu: s.txMu, state: s.state, branch: branchID}
+}
+
+func (s *MemoryStore) Transaction(fn func(store Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	tx := &MemoryStore{mu: &sync.Mutex{}, txMu: &sync.Mutex{}, state: s.state.clone(), branch: s.branch}
+	s.mu.Unlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	// Se copia el contenido para que las demás vistas del mismo estado vean los cambios
+	s.mu.Lock()
+	*s.state = *tx.state
+	s.mu.Unlock()
+	return nil
+}
+
+// owns indica si un registro de la sucursal branchID es de esta vista.
+func (s *MemoryStore) owns(branchID uint) bool {
+	return branchID == s.branch
+}
+
+// sees indica si la vista puede leer el producto: los suyos y los del catálogo común.
+func (s *MemoryStore) sees(product models.Product) bool {
+	return s.visible(product.BranchID)
+}
+
+// visible indica si la vista puede leer un registro del menú: los suyos y los del catálogo común.
+func (s *MemoryStore) visible(branchID uint) bool {
+	return s.owns(branchID) || branchID == tenant.Shared
+}
+
+// stamp asigna la sucursal de la vista a un registro nuevo, como el callback tenant:create.
+func (s *MemoryStore) stamp(branchID *uint) error {
+	if *branchID != 0 && *branchID != s.branch {
+		return tenant.ErrOtherBranch
+	}
+	*branchID = s.branch
+	return nil
+}
+
+// AuditLogs devuelve los registros de auditoría guardados, en orden.
+func (s *MemoryStore) AuditLogs() []models.AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AuditLog(nil), s.state.audit...)
+}
+
+// PriceHistory devuelve el historial de precios de un producto, en orden.
+func (s *MemoryStore) PriceHistory(productID uint) []models.ProductPrice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var history []models.ProductPrice
+	for _, price := range s.state.prices {
+		if price.ProductID == productID {
+			history = append(history, price)
+		}
+	}
+	return history
+}
+
+func (st *memoryState) clone() *memoryState {
+	c := &memoryState{
+		products:   make(map[uint]models.Product, len(st.products)),
+		orders:     make(map[uint]models.Order, len(st.orders)),
+		orderItems: make(map[uint]models.OrderItem, len(st.orderItems)),
+		branches:   make(map[uint]models.Branch, len(st.branches)),
+		overrides:  make(map[overrideKey]models.ProductOverride, len(st.overrides)),
+		prices:     append([]models.ProductPrice(nil), st.prices...),
+		payments:   append([]models.Payment(nil), st.payments...),
+		shifts:     make(map[uint]models.Shift, len(st.shifts)),
+		zReports:   make(map[uint]models.ZReport, len(st.zReports)),
+		printJobs:  make(map[uint]models.PrintJob, len(st.printJobs)),
+		audit:      append([]models.AuditLog(nil), st.audit...),
+		lastID:     make(map[string]uint, len(st.lastID)),
+
+		productTranslations:  make(map[uint]models.ProductTranslation, len(st.productTranslations)),
+		categoryTranslations: make(map[uint]models.CategoryTranslation, len(st.categoryTranslations)),
+		images:               make(map[uint]models.ProductImage, len(st.images)),
+	}
+	for table, id := range st.lastID {
+		c.lastID[table] = id
+	}
+	for id, product := range st.products {
+		c.products[id] = cloneProduct(product)
+	}
+	for id, order := range st.orders {
+		c.orders[id] = cloneOrder(order)
+	}
+	for id, item := range st.orderItems {
+		c.orderItems[id] = cloneOrderItem(item)
+	}
+	for id, branch := range st.branches {
+		c.branches[id] = branch
+	}
+	for key, override := range st.overrides {
+		c.overrides[key] = cloneOverride(override)
+	}
+	for id, job := range st.printJobs {
+		c.printJobs[id] = clonePrintJob(job)
+	}
+	for id, shift := range st.shifts {
+		c.shifts[id] = shift
+	}
+	for id, report := range st.zReports {
+		c.zReports[id] = cloneZReport(report)
+	}
+	for id, translation := range st.productTranslations {
+		c.productTranslations[id] = translation
+	}
+	for id, translation := range st.categoryTranslations {
+		c.categoryTranslations[id] = translation
+	}
+	for id, image := range st.images {
+		c.images[id] = image
+	}
+	return c
+}
+
+func (st *memoryState) nextID(table string) uint {
+	st.lastID[table]++
+	return st.lastID[table]
+}
+
+// Las copias evitan que quien recibe un registro modifique el almacenado sin llamar a Save.
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+func cloneProduct(p models.Product) models.Product {
+	p.Allergens = cloneStrings(p.Allergens)
+	p.DietaryTags = cloneStrings(p.DietaryTags)
+	p.Images = nil
+	return p
+}
+
+func cloneOverride(o models.ProductOverride) models.ProductOverride {
+	if o.Price != nil {
+		price := *o.Price
+		o.Price = &price
+	}
+	return o
+}
+
+func clonePrintJob(j models.PrintJob) models.PrintJob {
+	j.ItemIDs = append([]uint(nil), j.ItemIDs...)
+	j.Content = append([]byte(nil), j.Content...)
+	return j
+}
+
+func cloneZReport(r models.ZReport) models.ZReport {
+	r.TotalsByTender = append(json.RawMessage(nil), r.TotalsByTender...)
+	return r
+}
+
+func cloneOrder(o models.Order) models.Order {
+	o.GuestAllergies = cloneStrings(o.GuestAllergies)
+	o.Items = nil
+	return o
+}
+
+func cloneOrderItem(i models.OrderItem) models.OrderItem {
+	i.Modifiers = cloneStrings(i.Modifiers)
+	i.Allergens = cloneStrings(i.Allergens)
+	i.DietaryTags = cloneStrings(i.DietaryTags)
+	i.Warnings = nil
+	i.Product = models.Product{}
+	return i
+}
+
+// withProduct precarga el producto del item como lo hace Preload("Product").
+func (s *MemoryStore) withProduct(item models.OrderItem) models.OrderItem {
+	item = cloneOrderItem(item)
+	if product, ok := s.state.products[item.ProductID]; ok && s.sees(product) {
+		item.Product = cloneProduct(product)
+	}
+	item.AfterFind(nil)
+	return item
+}
+
+// withItems precarga los items de la comanda como lo hace Preload("Items.Product").
+func (s *MemoryStore) withItems(order models.Order) models.Order {
+	order = cloneOrder(order)
+	for _, item := range s.state.orderItems {
+		if item.OrderID != nil && *item.OrderID == order.ID && s.owns(item.BranchID) {
+			order.Items = append(order.Items, s.withProduct(item))
+		}
+	}
+	sort.Slice(order.Items, func(i, j int) bool { return order.Items[i].ID < order.Items[j].ID })
+	return order
+}
+
+type memoryProducts struct{ s *MemoryStore }
+
+func (r memoryProducts) FindByID(id uint) (*models.Product, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	product, ok := r.s.state.products[id]
+	if !ok || !r.s.sees(product) {
+		return nil, ErrNotFound
+	}
+	product = cloneProduct(product)
+	return &product, nil
+}
+
+func (r memoryProducts) FindByName(name string) (*models.Product, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var found models.Product
+	ok := false
+	for _, product := range r.s.state.products {
+		if product.Name == name && r.s.sees(product) && (!ok || product.BranchID > found.BranchID) {
+			found, ok = product, true
+		}
+	}
+	if !ok {
+		return nil, ErrNotFound
+	}
+	found = cloneProduct(found)
+	return &found, nil
+}
+
+// checkUniqueName imita la restricción UNIQUE sobre products (branch_id, name).
+func (st *memoryState) checkUniqueName(product *models.Product) error {
+	for id, existing := range st.products {
+		if id != product.ID && existing.BranchID == product.BranchID && existing.Name == product.Name {
+			return fmt.Errorf("duplicate product name: %s", product.Name)
+		}
+	}
+	return nil
+}
+
+func (r memoryProducts) Create(product *models.Product) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&product.BranchID); err != nil {
+		return err
+	}
+	if err := r.s.state.checkUniqueName(product); err != nil {
+		return err
+	}
+	if product.Allergens == nil {
+		product.Allergens = []string{}
+	}
+	if product.DietaryTags == nil {
+		product.DietaryTags = []string{}
+	}
+	product.ID = r.s.state.nextID("products")
+	r.s.state.products[product.ID] = cloneProduct(*product)
+	return nil
+}
+
+func (r memoryProducts) Save(product *models.Product) error {
+	if product.ID == 0 {
+		return r.Create(product)
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.products[product.ID]; !ok || !r.s.owns(stored.BranchID) {
+		return ErrNotFound
+	}
+	product.BranchID = r.s.branch
+	if err := r.s.state.checkUniqueName(product); err != nil {
+		return err
+	}
+	r.s.state.products[product.ID] = cloneProduct(*product)
+	return nil
+}
+
+func (r memoryProducts) FindOverride(productID uint) (*models.ProductOverride, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	override, ok := r.s.state.overrides[overrideKey{productID, r.s.branch}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	override = cloneOverride(override)
+	return &override, nil
+}
+
+func (r memoryProducts) SaveOverride(override *models.ProductOverride) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&override.BranchID); err != nil {
+		return err
+	}
+	if _, ok := r.s.state.products[override.ProductID]; !ok {
+		return fmt.Errorf("product %d does not exist", override.ProductID)
+	}
+	r.s.state.overrides[overrideKey{override.ProductID, override.BranchID}] = cloneOverride(*override)
+	return nil
+}
+
+func (r memoryProducts) DeleteOverride(override *models.ProductOverride) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.state.overrides, overrideKey{override.ProductID, r.s.branch})
+	return nil
+}
+
+func (r memoryProducts) RecordPrice(productID uint, price money.Amount, userID uint, at time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i := range r.s.state.prices {
+		price := r.s.state.prices[i]
+		if price.ProductID == productID && r.s.owns(price.BranchID) && price.EffectiveTo == nil {
+			closed := at
+			r.s.state.prices[i].EffectiveTo = &closed
+		}
+	}
+	r.s.state.prices = append(r.s.state.prices, models.ProductPrice{
+		ID:            r.s.state.nextID("product_prices"),
+		BranchID:      r.s.branch,
+		ProductID:     productID,
+		Price:         price,
+		EffectiveFrom: at,
+		ChangedBy:     userID,
+	})
+	return nil
+}
+
+type memoryOrders struct{ s *MemoryStore }
+
+func (r memoryOrders) FindByID(id uint) (*models.Order, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	order, ok := r.s.state.orders[id]
+	if !ok || !r.s.owns(order.BranchID) {
+		return nil, ErrNotFound
+	}
+	order = r.s.withItems(order)
+	return &order, nil
+}
+
+func (r memoryOrders) find(match func(models.Order) bool, less func(a, b models.Order) bool) []models.Order {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	orders := []models.Order{} // Como Find, sin resultados devuelve una lista vacía
+	for _, order := range r.s.state.orders {
+		if r.s.owns(order.BranchID) && match(order) {
+			orders = append(orders, r.s.withItems(order))
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return less(orders[i], orders[j]) })
+	return orders
+}
+
+// FindByIDForUpdate no necesita bloquear: las transacciones en memoria ya van una a la vez.
+func (r memoryOrders) FindByIDForUpdate(id uint) (*models.Order, error) {
+	return r.FindByID(id)
+}
+
+func (r memoryOrders) FindOpenByTable(tableNumber int) (*models.Order, error) {
+	orders := r.find(func(o models.Order) bool {
+		return o.TableNumber == tableNumber && o.Estado == "Pendiente"
+	}, func(a, b models.Order) bool { return a.ID < b.ID })
+	if len(orders) == 0 {
+		return nil, ErrNotFound
+	}
+	return &orders[0], nil
+}
+
+func (r memoryOrders) FindActive() ([]models.Order, error) {
+	return r.find(func(o models.Order) bool {
+		return o.Estado == "Pendiente"
+	}, func(a, b models.Order) bool { return a.TableNumber < b.TableNumber }), nil
+}
+
+func (r memoryOrders) FindByUser(userID uint) ([]models.Order, error) {
+	return r.find(func(o models.Order) bool {
+		return o.UserID == userID
+	}, func(a, b models.Order) bool { return a.OrderDate.After(b.OrderDate) }), nil
+}
+
+// openOrder busca la comanda pendiente de una mesa de la sucursal distinta de exceptID.
+func (st *memoryState) openOrder(branchID uint, tableNumber int, exceptID uint) (models.Order, bool) {
+	for id, order := range st.orders {
+		if id != exceptID && order.BranchID == branchID && order.TableNumber == tableNumber && order.Estado == "Pendiente" {
+			return order, true
+		}
+	}
+	return models.Order{}, false
+}
+
+// checkSingleOpen imita el índice único parcial idx_orders_open_table.
+func (st *memoryState) checkSingleOpen(order *models.Order) error {
+	if order.Estado != "Pendiente" {
+		return nil
+	}
+	if _, ok := st.openOrder(order.BranchID, order.TableNumber, order.ID); ok {
+		return fmt.Errorf("table %d already has an open order", order.TableNumber)
+	}
+	return nil
+}
+
+func (st *memoryState) createOrder(order *models.Order) {
+	if order.Version == 0 {
+		order.Version = 1
+	}
+	order.ID = st.nextID("orders")
+	st.orders[order.ID] = cloneOrder(*order)
+}
+
+func (r memoryOrders) Create(order *models.Order) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&order.BranchID); err != nil {
+		return err
+	}
+	if err := r.s.state.checkSingleOpen(order); err != nil {
+		return err
+	}
+	r.s.state.createOrder(order)
+	return nil
+}
+
+func (r memoryOrders) FindOrCreateOpen(order *models.Order) (*models.Order, bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&order.BranchID); err != nil {
+		return nil, false, err
+	}
+	if open, ok := r.s.state.openOrder(order.BranchID, order.TableNumber, 0); ok {
+		open = cloneOrder(open)
+		return &open, false, nil
+	}
+	r.s.state.createOrder(order)
+	return order, true, nil
+}
+
+func (r memoryOrders) Save(order *models.Order) error {
+	if order.ID == 0 {
+		return r.Create(order)
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.orders[order.ID]; !ok || !r.s.owns(stored.BranchID) || stored.Version != order.Version {
+		return ErrConflict
+	}
+	order.BranchID = r.s.branch
+	if err := r.s.state.checkSingleOpen(order); err != nil {
+		return err
+	}
+	order.Version++
+	r.s.state.orders[order.ID] = cloneOrder(*order)
+	return nil
+}
+
+type memoryOrderItems struct{ s *MemoryStore }
+
+func (r memoryOrderItems) FindByID(id uint) (*models.OrderItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	item, ok := r.s.state.orderItems[id]
+	if !ok || !r.s.owns(item.BranchID) {
+		return nil, ErrNotFound
+	}
+	item = r.s.withProduct(item)
+	return &item, nil
+}
+
+func (r memoryOrderItems) FindByOrder(orderID uint) ([]models.OrderItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var items []models.OrderItem
+	for _, item := range r.s.state.orderItems {
+		if item.OrderID != nil && *item.OrderID == orderID && r.s.owns(item.BranchID) {
+			items = append(items, cloneOrderItem(item))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (r memoryOrderItems) FindForKitchen() ([]models.OrderItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []models.OrderItem{}
+	for _, item := range r.s.state.orderItems {
+		if !r.s.owns(item.BranchID) || item.Held || item.FiredAt == nil || item.OrderID == nil {
+			continue
+		}
+		if item.Estado == models.OrderItemAnulado || item.KitchenStatus == models.KitchenListo {
+			continue
+		}
+		if order, ok := r.s.state.orders[*item.OrderID]; !ok || order.Estado != "Pendiente" {
+			continue
+		}
+		items = append(items, r.s.withProduct(item))
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].FiredAt.Equal(*items[j].FiredAt) {
+			return items[i].FiredAt.Before(*items[j].FiredAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+func (r memoryOrderItems) FindVoided(from time.Time, to time.Time) ([]models.OrderItem, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	items := []models.OrderItem{}
+	for _, item := range r.s.state.orderItems {
+		if !r.s.owns(item.BranchID) || item.VoidedAt == nil || item.VoidedAt.Before(from) || item.VoidedAt.After(to) {
+			continue
+		}
+		if item.Estado == models.OrderItemAnulado || item.Estado == models.OrderItemCortesia {
+			items = append(items, cloneOrderItem(item))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].VoidedAt.Equal(*items[j].VoidedAt) {
+			return items[i].VoidedAt.Before(*items[j].VoidedAt)
+		}
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+func (r memoryOrderItems) Create(item *models.OrderItem) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&item.BranchID); err != nil {
+		return err
+	}
+	if _, ok := r.s.state.products[item.ProductID]; !ok {
+		return fmt.Errorf("product %d does not exist", item.ProductID)
+	}
+	// Valores por defecto de las columnas
+	if item.Estado == "" {
+		item.Estado = models.OrderItemActivo
+	}
+	if item.KitchenStatus == "" {
+		item.KitchenStatus = models.KitchenRecibido
+	}
+	if item.Course == 0 {
+		item.Course = 1
+	}
+	now := time.Now()
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = now
+	}
+	item.UpdatedAt = now
+	item.ID = r.s.state.nextID("order_items")
+	r.s.state.orderItems[item.ID] = cloneOrderItem(*item)
+	return nil
+}
+
+func (r memoryOrderItems) Save(item *models.OrderItem) error {
+	if item.ID == 0 {
+		return r.Create(item)
+	}
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.orderItems[item.ID]; ok && !r.s.owns(stored.BranchID) {
+		// Como el UPDATE filtrado por sucursal: no cambia ninguna fila
+		return nil
+	}
+	item.BranchID = r.s.branch
+	item.UpdatedAt = time.Now()
+	r.s.state.orderItems[item.ID] = cloneOrderItem(*item)
+	return nil
+}
+
+func (r memoryOrderItems) Delete(item *models.OrderItem) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.orderItems[item.ID]; ok && r.s.owns(stored.BranchID) {
+		delete(r.s.state.orderItems, item.ID)
+	}
+	return nil
+}
+
+type memoryPayments struct{ s *MemoryStore }
+
+func (r memoryPayments) FindByOrder(orderID uint) ([]models.Payment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	payments := []models.Payment{}
+	for _, payment := range r.s.state.payments {
+		if payment.OrderID == orderID && r.s.owns(payment.BranchID) {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r memoryPayments) FindByShift(shiftID uint) ([]models.Payment, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	payments := []models.Payment{}
+	for _, payment := range r.s.state.payments {
+		if payment.ShiftID == shiftID && r.s.owns(payment.BranchID) {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r memoryPayments) Create(payment *models.Payment) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&payment.BranchID); err != nil {
+		return err
+	}
+	if payment.CreatedAt.IsZero() {
+		payment.CreatedAt = time.Now()
+	}
+	payment.ID = r.s.state.nextID("payments")
+	r.s.state.payments = append(r.s.state.payments, *payment)
+	return nil
+}
+
+type memoryShifts struct{ s *MemoryStore }
+
+// openShift busca el turno abierto de la vista.
+func (r memoryShifts) openShift() (models.Shift, bool) {
+	for _, shift := range r.s.state.shifts {
+		if r.s.owns(shift.BranchID) && shift.Estado == models.ShiftAbierto {
+			return shift, true
+		}
+	}
+	return models.Shift{}, false
+}
+
+func (r memoryShifts) FindOpen() (*models.Shift, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	shift, ok := r.openShift()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &shift, nil
+}
+
+func (r memoryShifts) CreateOpen(shift *models.Shift) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&shift.BranchID); err != nil {
+		return false, err
+	}
+	if _, ok := r.openShift(); ok {
+		return false, nil
+	}
+	shift.ID = r.s.state.nextID("shifts")
+	stored := *shift
+	stored.Payments = nil
+	r.s.state.shifts[shift.ID] = stored
+	return true, nil
+}
+
+func (r memoryShifts) Save(shift *models.Shift) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.shifts[shift.ID]; !ok || !r.s.owns(stored.BranchID) {
+		return ErrNotFound
+	}
+	stored := *shift
+	stored.Payments = nil
+	r.s.state.shifts[shift.ID] = stored
+	return nil
+}
+
+func (r memoryShifts) FindZReport(shiftID uint) (*models.ZReport, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, report := range r.s.state.zReports {
+		if report.ShiftID == shiftID && r.s.owns(report.BranchID) {
+			report = cloneZReport(report)
+			return &report, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memoryShifts) CreateZReport(report *models.ZReport) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&report.BranchID); err != nil {
+		return err
+	}
+	// Como el índice único idx_z_reports_shift_id
+	for _, existing := range r.s.state.zReports {
+		if existing.ShiftID == report.ShiftID {
+			return fmt.Errorf("shift %d already has a z report", report.ShiftID)
+		}
+	}
+	report.ID = r.s.state.nextID("z_reports")
+	r.s.state.zReports[report.ID] = cloneZReport(*report)
+	return nil
+}
+
+type memoryTranslations struct{ s *MemoryStore }
+
+// byBranch ordena las traducciones con las del catálogo común primero, como ORDER BY branch_id.
+func byBranch[T any](rows []T, branchID func(T) uint) {
+	sort.SliceStable(rows, func(i, j int) bool { return branchID(rows[i]) < branchID(rows[j]) })
+}
+
+func (r memoryTranslations) FindProduct(productID uint, locale string) (*models.ProductTranslation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var found *models.ProductTranslation
+	for _, translation := range r.s.state.productTranslations {
+		translation := translation
+		if translation.ProductID == productID && translation.Locale == locale && r.s.visible(translation.BranchID) &&
+			(found == nil || translation.BranchID > found.BranchID) {
+			found = &translation
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+func (r memoryTranslations) FindByProduct(productID uint) ([]models.ProductTranslation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	translations := []models.ProductTranslation{}
+	for _, translation := range r.s.state.productTranslations {
+		if translation.ProductID == productID && r.s.visible(translation.BranchID) {
+			translations = append(translations, translation)
+		}
+	}
+	sort.Slice(translations, func(i, j int) bool { return translations[i].Locale < translations[j].Locale })
+	return translations, nil
+}
+
+func (r memoryTranslations) ForProducts(locale string, productIDs []uint) ([]models.ProductTranslation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	wanted := map[uint]bool{}
+	for _, id := range productIDs {
+		wanted[id] = true
+	}
+	translations := []models.ProductTranslation{}
+	for _, translation := range r.s.state.productTranslations {
+		if translation.Locale == locale && wanted[translation.ProductID] && r.s.visible(translation.BranchID) {
+			translations = append(translations, translation)
+		}
+	}
+	byBranch(translations, func(t models.ProductTranslation) uint { return t.BranchID })
+	return translations, nil
+}
+
+func (r memoryTranslations) SaveProduct(translation *models.ProductTranslation) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&translation.BranchID); err != nil {
+		return err
+	}
+	if _, ok := r.s.state.products[translation.ProductID]; !ok {
+		return fmt.Errorf("product %d does not exist", translation.ProductID)
+	}
+	for id, existing := range r.s.state.productTranslations {
+		if existing.BranchID == translation.BranchID && existing.ProductID == translation.ProductID && existing.Locale == translation.Locale {
+			translation.ID = id
+		}
+	}
+	if translation.ID == 0 {
+		translation.ID = r.s.state.nextID("product_translations")
+	}
+	r.s.state.productTranslations[translation.ID] = *translation
+	return nil
+}
+
+func (r memoryTranslations) DeleteProduct(translation *models.ProductTranslation) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.productTranslations[translation.ID]; ok && r.s.owns(stored.BranchID) {
+		delete(r.s.state.productTranslations, translation.ID)
+	}
+	return nil
+}
+
+func (r memoryTranslations) FindCategory(category string, locale string) (*models.CategoryTranslation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var found *models.CategoryTranslation
+	for _, translation := range r.s.state.categoryTranslations {
+		translation := translation
+		if translation.Category == category && translation.Locale == locale && r.s.visible(translation.BranchID) &&
+			(found == nil || translation.BranchID > found.BranchID) {
+			found = &translation
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+	return found, nil
+}
+
+func (r memoryTranslations) ForCategories(locale string, categories []string) ([]models.CategoryTranslation, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	wanted := map[string]bool{}
+	for _, category := range categories {
+		wanted[category] = true
+	}
+	translations := []models.CategoryTranslation{}
+	for _, translation := range r.s.state.categoryTranslations {
+		if translation.Locale == locale && wanted[translation.Category] && r.s.visible(translation.BranchID) {
+			translations = append(translations, translation)
+		}
+	}
+	byBranch(translations, func(t models.CategoryTranslation) uint { return t.BranchID })
+	return translations, nil
+}
+
+func (r memoryTranslations) SaveCategory(translation *models.CategoryTranslation) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&translation.BranchID); err != nil {
+		return err
+	}
+	for id, existing := range r.s.state.categoryTranslations {
+		if existing.BranchID == translation.BranchID && existing.Category == translation.Category && existing.Locale == translation.Locale {
+			translation.ID = id
+		}
+	}
+	if translation.ID == 0 {
+		translation.ID = r.s.state.nextID("category_translations")
+	}
+	r.s.state.categoryTranslations[translation.ID] = *translation
+	return nil
+}
+
+type memoryImages struct{ s *MemoryStore }
+
+func (r memoryImages) FindByID(id uint) (*models.ProductImage, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	image, ok := r.s.state.images[id]
+	if !ok || !r.s.visible(image.BranchID) {
+		return nil, ErrNotFound
+	}
+	return &image, nil
+}
+
+func (r memoryImages) FindByProduct(productID uint) ([]models.ProductImage, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	images := []models.ProductImage{}
+	for _, image := range r.s.state.images {
+		if image.ProductID == productID && r.s.visible(image.BranchID) {
+			images = append(images, image)
+		}
+	}
+	sort.Slice(images, func(i, j int) bool { return images[i].ID < images[j].ID })
+	return images, nil
+}
+
+func (r memoryImages) Create(image *models.ProductImage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&image.BranchID); err != nil {
+		return err
+	}
+	if _, ok := r.s.state.products[image.ProductID]; !ok {
+		return fmt.Errorf("product %d does not exist", image.ProductID)
+	}
+	if image.CreatedAt.IsZero() {
+		image.CreatedAt = time.Now()
+	}
+	image.ID = r.s.state.nextID("product_images")
+	r.s.state.images[image.ID] = *image
+	return nil
+}
+
+func (r memoryImages) Delete(image *models.ProductImage) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if stored, ok := r.s.state.images[image.ID]; ok && r.s.owns(stored.BranchID) {
+		delete(r.s.state.images, image.ID)
+	}
+	return nil
+}
+
+type memoryPrintJobs struct{ s *MemoryStore }
+
+func (r memoryPrintJobs) FindByID(id uint) (*models.PrintJob, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	job, ok := r.s.state.printJobs[id]
+	if !ok || !r.s.owns(job.BranchID) {
+		return nil, ErrNotFound
+	}
+	job = clonePrintJob(job)
+	return &job, nil
+}
+
+// FindByIDForUpdate no necesita bloquear: las transacciones en memoria ya van una a la vez.
+func (r memoryPrintJobs) FindByIDForUpdate(id uint) (*models.PrintJob, error) {
+	return r.FindByID(id)
+}
+
+// find devuelve los trabajos de la sucursal que cumplen match, en el orden dado por less.
+func (r memoryPrintJobs) find(match func(models.PrintJob) bool, less func(a, b models.PrintJob) bool) []models.PrintJob {
+	jobs := []models.PrintJob{}
+	for _, job := range r.s.state.printJobs {
+		if r.s.owns(job.BranchID) && match(job) {
+			jobs = append(jobs, clonePrintJob(job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return less(jobs[i], jobs[j]) })
+	return jobs
+}
+
+func (r memoryPrintJobs) FindByEstado(estado string) ([]models.PrintJob, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.find(
+		func(job models.PrintJob) bool { return job.Estado == estado },
+		func(a, b models.PrintJob) bool {
+			if !a.CreatedAt.Equal(b.CreatedAt) {
+				return a.CreatedAt.Before(b.CreatedAt)
+			}
+			return a.ID < b.ID
+		},
+	), nil
+}
+
+func (r memoryPrintJobs) ClaimDue(now time.Time, limit int) ([]models.PrintJob, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	jobs := r.find(
+		func(job models.PrintJob) bool {
+			due := job.Estado == models.PrintJobPendiente || job.Estado == models.PrintJobEnviando
+			return due && !job.NextAttemptAt.After(now)
+		},
+		func(a, b models.PrintJob) bool {
+			if !a.NextAttemptAt.Equal(b.NextAttemptAt) {
+				return a.NextAttemptAt.Before(b.NextAttemptAt)
+			}
+			return a.ID < b.ID
+		},
+	)
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+func (r memoryPrintJobs) Create(job *models.PrintJob) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&job.BranchID); err != nil {
+		return err
+	}
+	if job.Estado == "" {
+		job.Estado = models.PrintJobPendiente
+	}
+	now := time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+	job.ID = r.s.state.nextID("print_jobs")
+	r.s.state.printJobs[job.ID] = clonePrintJob(*job)
+	return nil
+}
+
+func (r memoryPrintJobs) Save(job *models.PrintJob) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if existing, ok := r.s.state.printJobs[job.ID]; !ok || !r.s.owns(existing.BranchID) {
+		return ErrNotFound
+	}
+	job.UpdatedAt = time.Now()
+	r.s.state.printJobs[job.ID] = clonePrintJob(*job)
+	return nil
+}
+
+type memoryAudit struct{ s *MemoryStore }
+
+func (r memoryAudit) Record(entry *models.AuditLog) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if err := r.s.stamp(&entry.BranchID); err != nil {
+		return err
+	}
+	entry.ID = r.s.state.nextID("audit_logs")
+	r.s.state.audit = append(r.s.state.audit, *entry)
+	return nil
+}
+
+type memoryBranches struct{ s *MemoryStore }
+
+func (r memoryBranches) FindByID(id uint) (*models.Branch, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	branch, ok := r.s.state.branches[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &branch, nil
+}
+
+func (r memoryBranches) FindAll() ([]models.Branch, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var branches []models.Branch
+	for id, branch := range r.s.state.branches {
+		if id != tenant.Shared {
+			branches = append(branches, branch)
+		}
+	}
+	sort.Slice(branches, func(i, j int) bool { return branches[i].ID < branches[j].ID })
+	return branches, nil
+}
+
+func (r memoryBranches) Create(branch *models.Branch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.state.branches {
+		if existing.Name == branch.Name {
+			return fmt.Errorf("duplicate branch name: %s", branch.Name)
+		}
+	}
+	if branch.CreatedAt.IsZero() {
+		branch.CreatedAt = time.Now()
+	}
+	branch.ID = r.s.state.nextID("branches")
+	r.s.state.branches[branch.ID] = *branch
+	return nil
+}
@@ -0,0 +1,178 @@
+// Package repository define el acceso a los datos del microservicio detrás de interfaces,
+// con una implementación sobre GORM/Postgres y otra en memoria para probar la lógica sin base de datos.
+// Los repositorios de un Store limitado con Branch solo leen y escriben datos de esa sucursal,
+// más los productos del catálogo común.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FelipeGeraldoblufus/Comandas-ms/models"
+	"github.com/FelipeGeraldoblufus/Comandas-ms/money"
+)
+
+// ErrNotFound se devuelve cuando el registro buscado no existe.
+var ErrNotFound = errors.New("record not found")
+
+// ErrConflict se devuelve al guardar una comanda que otro cambió desde que se leyó.
+var ErrConflict = errors.New("order was modified concurrently; reload it and try again")
+
+// ProductRepository accede a los productos del menú.
+type ProductRepository interface {
+	FindByID(id uint) (*models.Product, error)
+	// FindByName busca un producto por nombre, esté archivado o no. Si la sucursal tiene un
+	// producto con el mismo nombre que uno del catálogo común, devuelve el de la sucursal.
+	FindByName(name string) (*models.Product, error)
+	Create(product *models.Product) error
+	// Save guarda los campos del producto. Si el producto no es de la sucursal devuelve ErrNotFound.
+	Save(product *models.Product) error
+	// FindOverride busca el precio y disponibilidad de un producto del catálogo común en la sucursal.
+	FindOverride(productID uint) (*models.ProductOverride, error)
+	SaveOverride(override *models.ProductOverride) error
+	DeleteOverride(override *models.ProductOverride) error
+	// RecordPrice cierra el precio vigente del producto y abre uno nuevo desde at.
+	RecordPrice(productID uint, price money.Amount, userID uint, at time.Time) error
+}
+
+// OrderRepository accede a las comandas. Las lecturas devuelven los items con su producto.
+type OrderRepository interface {
+	FindByID(id uint) (*models.Order, error)
+	// FindByIDForUpdate es FindByID bloqueando la comanda hasta confirmar la transacción.
+	FindByIDForUpdate(id uint) (*models.Order, error)
+	// FindOpenByTable busca la comanda pendiente de una mesa.
+	FindOpenByTable(tableNumber int) (*models.Order, error)
+	// FindActive lista las comandas pendientes ordenadas por mesa.
+	FindActive() ([]models.Order, error)
+	// FindByUser lista las comandas de un usuario, de la más reciente a la más antigua.
+	FindByUser(userID uint) ([]models.Order, error)
+	Create(order *models.Order) error
+	// FindOrCreateOpen devuelve la comanda pendiente de la mesa de order, o guarda order como la
+	// nueva comanda pendiente si la mesa no tiene una. created indica si se creó. Dos llamadas
+	// concurrentes para la misma mesa nunca crean dos comandas pendientes.
+	FindOrCreateOpen(order *models.Order) (open *models.Order, created bool, err error)
+	// Save guarda los campos de la comanda, sin tocar sus items. Solo la guarda si su Version
+	// sigue siendo la leída, y la aumenta; si no, devuelve ErrConflict.
+	Save(order *models.Order) error
+}
+
+// OrderItemRepository accede a los items de las comandas.
+type OrderItemRepository interface {
+	// FindByID busca un item con su producto.
+	FindByID(id uint) (*models.OrderItem, error)
+	// FindByOrder lista los items de una comanda, sin su producto.
+	FindByOrder(orderID uint) ([]models.OrderItem, error)
+	// FindForKitchen lista con su producto los items de comandas pendientes que ya se enviaron a
+	// cocina y no están anulados ni listos, en el orden en que se enviaron.
+	FindForKitchen() ([]models.OrderItem, error)
+	// FindVoided lista sin su producto los items anulados o regalados entre from y to, incluidos.
+	FindVoided(from time.Time, to time.Time) ([]models.OrderItem, error)
+	Create(item *models.OrderItem) error
+	// Save guarda los campos del item, sin tocar su producto.
+	Save(item *models.OrderItem) error
+	Delete(item *models.OrderItem) error
+}
+
+// BranchRepository accede a las sucursales. No está limitado a una sucursal.
+type BranchRepository interface {
+	FindByID(id uint) (*models.Branch, error)
+	// FindAll lista las sucursales por ID, sin el catálogo común.
+	FindAll() ([]models.Branch, error)
+	Create(branch *models.Branch) error
+}
+
+// PaymentRepository accede a los pagos de las comandas.
+type PaymentRepository interface {
+	// FindByOrder lista los pagos de la comanda en el orden en que se registraron.
+	FindByOrder(orderID uint) ([]models.Payment, error)
+	// FindByShift lista los pagos del turno en el orden en que se registraron.
+	FindByShift(shiftID uint) ([]models.Payment, error)
+	Create(payment *models.Payment) error
+}
+
+// ShiftRepository accede a los turnos de caja y a sus informes Z.
+type ShiftRepository interface {
+	// FindOpen busca el turno abierto de la sucursal.
+	FindOpen() (*models.Shift, error)
+	// CreateOpen guarda shift como el turno abierto de la sucursal si no tiene otro abierto. Si
+	// ya lo tiene no guarda nada y created es false; dos aperturas a la vez nunca abren dos turnos.
+	CreateOpen(shift *models.Shift) (created bool, err error)
+	// Save guarda los campos del turno, sin tocar sus pagos.
+	Save(shift *models.Shift) error
+	// FindZReport busca el informe Z de un turno.
+	FindZReport(shiftID uint) (*models.ZReport, error)
+	CreateZReport(report *models.ZReport) error
+}
+
+// TranslationRepository accede a las traducciones del menú de la sucursal y del catálogo común.
+// Cuando hay las dos, la de la sucursal reemplaza a la común.
+type TranslationRepository interface {
+	// FindProduct busca la traducción de un producto; si la sucursal tiene la suya, devuelve esa.
+	FindProduct(productID uint, locale string) (*models.ProductTranslation, error)
+	// FindByProduct lista las traducciones de un producto ordenadas por idioma.
+	FindByProduct(productID uint) ([]models.ProductTranslation, error)
+	// ForProducts lista las traducciones a locale de los productos dados, las del catálogo común
+	// primero.
+	ForProducts(locale string, productIDs []uint) ([]models.ProductTranslation, error)
+	// SaveProduct crea la traducción en la sucursal, o reemplaza la suya del mismo producto e
+	// idioma si ya existe.
+	SaveProduct(translation *models.ProductTranslation) error
+	DeleteProduct(translation *models.ProductTranslation) error
+	// FindCategory busca la traducción de una categoría; si la sucursal tiene la suya, devuelve esa.
+	FindCategory(category string, locale string) (*models.CategoryTranslation, error)
+	// ForCategories lista las traducciones a locale de las categorías dadas, las del catálogo
+	// común primero.
+	ForCategories(locale string, categories []string) ([]models.CategoryTranslation, error)
+	// SaveCategory crea la traducción en la sucursal, o reemplaza la suya de la misma categoría e
+	// idioma si ya existe.
+	SaveCategory(translation *models.CategoryTranslation) error
+}
+
+// ImageRepository accede a las fotos de los productos de la sucursal y del catálogo común.
+type ImageRepository interface {
+	FindByID(id uint) (*models.ProductImage, error)
+	// FindByProduct lista las fotos de un producto en el orden en que se subieron.
+	FindByProduct(productID uint) ([]models.ProductImage, error)
+	Create(image *models.ProductImage) error
+	Delete(image *models.ProductImage) error
+}
+
+// PrintJobRepository accede a la cola de impresión de los tickets de cocina.
+type PrintJobRepository interface {
+	FindByID(id uint) (*models.PrintJob, error)
+	// FindByIDForUpdate es FindByID bloqueando el trabajo hasta confirmar la transacción.
+	FindByIDForUpdate(id uint) (*models.PrintJob, error)
+	// FindByEstado lista los trabajos en ese estado, del más antiguo al más reciente.
+	FindByEstado(estado string) ([]models.PrintJob, error)
+	// ClaimDue devuelve hasta limit trabajos pendientes cuyo próximo intento ya llegó, o que
+	// quedaron Enviando después de su plazo porque el proceso que los enviaba se cayó. Dentro de
+	// una transacción los bloquea hasta confirmarla, para que otra réplica no los tome también.
+	ClaimDue(now time.Time, limit int) ([]models.PrintJob, error)
+	Create(job *models.PrintJob) error
+	Save(job *models.PrintJob) error
+}
+
+// AuditRepository guarda los registros de auditoría.
+type AuditRepository interface {
+	Record(entry *models.AuditLog) error
+}
+
+// Store agrupa los repositorios y permite usarlos dentro de una transacción.
+type Store interface {
+	Products() ProductRepository
+	Orders() OrderRepository
+	OrderItems() OrderItemRepository
+	Payments() PaymentRepository
+	Shifts() ShiftRepository
+	Translations() TranslationRepository
+	Images() ImageRepository
+	PrintJobs() PrintJobRepository
+	Audit() AuditRepository
+	Branches() BranchRepository
+	// Branch devuelve el Store limitado a una sucursal: las lecturas solo ven sus datos y los
+	// registros nuevos quedan en ella.
+	Branch(branchID uint) Store
+	// Transaction ejecuta fn con un Store transaccional: si fn devuelve error nada de lo
+	// que hizo queda guardado.
+	Transaction(fn func(store Store) error) error
+}
@@ -0,0 +1,149 @@
+// Package tenant separa los datos de cada sucursal. La sucursal viaja en el contexto de las
+// consultas de GORM y los callbacks que instala Register la aplican a toda consulta, modificación
+// o inserción sobre un modelo con campo BranchID, de modo que una sucursal no puede leer ni
+// cambiar datos de otra aunque el código olvide filtrar.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Shared es la sucursal del catálogo común: sus productos los ven todas las sucursales.
+const Shared uint = 0
+
+// CatalogTables son las tablas del menú cuyos registros del catálogo común ven todas las
+// sucursales: los productos con sus traducciones e imágenes, y las traducciones de categorías.
+var CatalogTables = []string{"products", "product_translations", "category_translations", "product_images"}
+
+// ErrNoBranch se devuelve al consultar un modelo de sucursal sin sucursal en el contexto.
+var ErrNoBranch = errors.New("no branch in the query context")
+
+// ErrOtherBranch se devuelve al insertar un registro que pertenece a otra sucursal.
+var ErrOtherBranch = errors.New("the record belongs to another branch")
+
+type branchKey struct{}
+
+// WithBranch devuelve un contexto cuyas consultas se limitan a la sucursal dada.
+func WithBranch(ctx context.Context, branchID uint) context.Context {
+	return context.WithValue(ctx, branchKey{}, branchID)
+}
+
+// BranchFrom devuelve la sucursal del contexto.
+func BranchFrom(ctx context.Context) (uint, bool) {
+	branchID, ok := ctx.Value(branchKey{}).(uint)
+	return branchID, ok
+}
+
+// Scope devuelve la conexión limitada a la sucursal dada.
+func Scope(db *gorm.DB, branchID uint) *gorm.DB {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.WithContext(WithBranch(ctx, branchID))
+}
+
+// Register instala los callbacks que limitan las consultas a la sucursal del contexto. Las
+// tablas de sharedTables (por ejemplo "products") también muestran los registros del catálogo
+// común, pero solo se pueden modificar los de la propia sucursal.
+func Register(db *gorm.DB, sharedTables ...string) error {
+	shared := map[string]bool{}
+	for _, table := range sharedTables {
+		shared[table] = true
+	}
+
+	callbacks := []error{
+		db.Callback().Query().Before("gorm:query").Register("tenant:query", filter(shared)),
+		db.Callback().Row().Before("gorm:row").Register("tenant:row", filter(shared)),
+		db.Callback().Update().Before("gorm:update").Register("tenant:update", filter(nil)),
+		db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", filter(nil)),
+		db.Callback().Create().Before("gorm:create").Register("tenant:create", assign),
+	}
+	for _, err := range callbacks {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// branchField devuelve el campo BranchID del modelo de la sentencia, si lo tiene.
+func branchField(stmt *gorm.Statement) *schema.Field {
+	if stmt.Schema == nil {
+		return nil
+	}
+	return stmt.Schema.LookUpField("BranchID")
+}
+
+// filter agrega a la sentencia la condición de sucursal.
+func filter(shared map[string]bool) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		field := branchField(db.Statement)
+		if field == nil || db.Error != nil {
+			return
+		}
+		branchID, ok := BranchFrom(db.Statement.Context)
+		if !ok {
+			db.AddError(fmt.Errorf("%s: %w", db.Statement.Table, ErrNoBranch))
+			return
+		}
+
+		column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+		var condition clause.Expression = clause.Eq{Column: column, Value: branchID}
+		if shared[db.Statement.Schema.Table] && branchID != Shared {
+			condition = clause.IN{Column: column, Values: []interface{}{Shared, branchID}}
+		}
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{condition}})
+	}
+}
+
+// assign completa el BranchID de los registros nuevos con la sucursal del contexto. Si la
+// inserción actualiza un registro existente (ON CONFLICT DO UPDATE, como hace Save) solo puede
+// actualizar los de la misma sucursal.
+func assign(db *gorm.DB) {
+	field := branchField(db.Statement)
+	if field == nil || db.Error != nil {
+		return
+	}
+	branchID, ok := BranchFrom(db.Statement.Context)
+	if !ok {
+		db.AddError(fmt.Errorf("%s: %w", db.Statement.Table, ErrNoBranch))
+		return
+	}
+
+	set := func(record reflect.Value) {
+		value, zero := field.ValueOf(db.Statement.Context, record)
+		if !zero && value != branchID {
+			db.AddError(ErrOtherBranch)
+			return
+		}
+		if err := field.Set(db.Statement.Context, record, branchID); err != nil {
+			db.AddError(err)
+		}
+	}
+	switch records := db.Statement.ReflectValue; records.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < records.Len(); i++ {
+			set(reflect.Indirect(records.Index(i)))
+		}
+	case reflect.Struct:
+		set(records)
+	}
+
+	if c, ok := db.Statement.Clauses["ON CONFLICT"]; ok {
+		if onConflict, ok := c.Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+			onConflict.Where.Exprs = append(onConflict.Where.Exprs, clause.Eq{
+				Column: clause.Column{Table: db.Statement.Table, Name: field.DBName},
+				Value:  branchID,
+			})
+			db.Statement.AddClause(onConflict)
+		}
+	}
+}